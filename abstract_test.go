@@ -0,0 +1,157 @@
+//go:build linux && !network
+
+package gipc
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+func abstractServerConfig(name string) *ServerConfig {
+	scon := NewServerConfig(name)
+	scon.Transport = &UnixTransport{Abstract: true}
+	return scon
+}
+
+func abstractClientConfig(name string) *ClientConfig {
+	ccon := NewClientConfig(name)
+	ccon.Transport = &UnixTransport{Abstract: true}
+	return ccon
+}
+
+func TestUnixAbstract(t *testing.T) {
+
+	Sleep()
+
+	sc, err := StartServer(abstractServerConfig("test_abstract"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sc.Close()
+
+	if addr := sc.GetListener().Addr().String(); addr != "@test_abstract" {
+		t.Errorf("Got %q, Wanted %q", addr, "@test_abstract")
+	}
+	if _, err = os.Stat(getSocketName(0, "test_abstract")); !os.IsNotExist(err) {
+		t.Errorf("no socket file should be created but got: %v", err)
+	}
+
+	Sleep()
+
+	cc, err := StartClient(abstractClientConfig("test_abstract"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+
+	waitForConnected(t, &sc.Actor)
+	waitForConnected(t, &cc.Actor)
+
+	if err = cc.Write(5, []byte("abstract")); err != nil {
+		t.Fatal(err)
+	}
+	m, err := sc.Read()
+	if err != nil || m.MsgType != 5 || string(m.Data) != "abstract" {
+		t.Errorf("unexpected message %v %v", m, err)
+	}
+
+	//a client of the filesystem socket doesn't reach the abstract server
+	ccon := NewClientConfig("test_abstract")
+	ccon.Timeout = time.Second
+	if _, err = StartClient(ccon); err == nil {
+		t.Error("the client of the socket file should not connect")
+	}
+}
+
+func TestUnixAbstractReconnect(t *testing.T) {
+
+	Sleep()
+
+	scon := abstractServerConfig("test_abstract_reconnect")
+	sc, err := StartServer(scon)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	Sleep()
+
+	cc, err := StartClient(abstractClientConfig("test_abstract_reconnect"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+
+	waitForConnected(t, &sc.Actor)
+	waitForConnected(t, &cc.Actor)
+
+	//the name is released when the server closes so a new server can listen on it
+	sc.Close()
+
+	sc2, err := StartServer(scon)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sc2.Close()
+
+	reconnecting := false
+	for {
+		m, err := cc.Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.Status == "Reconnecting" {
+			reconnecting = true
+		} else if m.Status == "Connected" && reconnecting {
+			break
+		}
+	}
+	waitForConnected(t, &sc2.Actor)
+}
+
+func TestUnixAbstractMultiClient(t *testing.T) {
+
+	Sleep()
+
+	scon := abstractServerConfig("test_abstract_multi")
+	scon.MultiClient = true
+	sc, err := StartServer(scon)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sc.Close()
+
+	mux := NewServeMux()
+	mux.HandleFunc(5, func(ctx context.Context, a *Actor, msg *Message) {
+		a.Reply(msg, msg.Data)
+	})
+	go sc.Serve(mux)
+
+	Sleep()
+
+	for _, name := range []string{"first", "second"} {
+		ccon := abstractClientConfig("test_abstract_multi")
+		ccon.MultiClient = true
+		cc, err := StartClient(ccon)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer cc.Close()
+		drainClient(cc)
+
+		reply, err := cc.Request(context.Background(), 5, []byte(name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(reply.Data) != name {
+			t.Errorf("Got %q, Wanted %q", reply.Data, name)
+		}
+	}
+
+	for _, name := range []string{"test_abstract_multi", "test_abstract_multi_manager"} {
+		if _, err = os.Stat(getSocketName(0, name)); !os.IsNotExist(err) {
+			t.Errorf("no socket file should be created but got: %v", err)
+		}
+	}
+}
@@ -15,6 +15,8 @@ import (
 
 var TimeoutMessage = &Message{MsgType: 2, Err: errors.New("timed_out")}
 
+var errReceivedChannelClosed = errors.New("the received channel has been closed")
+
 func NewActor(ac *ActorConfig) Actor {
 
 	logger := logrus.New()
@@ -36,32 +38,82 @@ func NewActor(ac *ActorConfig) Actor {
 	})
 
 	return Actor{
-		status:   NotConnected,
-		received: make(chan *Message),
-		toWrite:  make(chan *Message),
-		logger:   logger,
-		config:   ac,
-		mutex:    &sync.Mutex{},
+		status:    NotConnected,
+		received:  make(chan *Message),
+		toWrite:   make(chan *Message),
+		logger:    logger,
+		config:    ac,
+		mutex:     &sync.Mutex{},
+		pending:   newPendingRequests(),
+		routes:    newRouteTable(),
+		streams:   newStreamSession(ac.IsServer),
+		fragments: newFragmentTable(),
+		fds:       newFDQueue(),
+		hooks:     newStatusHooks(),
+		closed:    make(chan struct{}),
+		closeOnce: &sync.Once{},
+		failed:    make(chan struct{}),
+		failOnce:  &sync.Once{},
 	}
 }
 
 // Read - blocking function, reads each message received
 // if MsgType is a negative number it's an internal message
 func (a *Actor) Read() (*Message, error) {
+	return a.readInbound(context.Background(), nil)
+}
+
+// ReadContext - reads the next message received, returning ctx.Err() when ctx is
+// cancelled or its deadline is exceeded before a message arrives
+func (a *Actor) ReadContext(ctx context.Context) (*Message, error) {
+	return a.readInbound(ctx, nil)
+}
+
+// readUntilClosed - Read which also returns errReceivedChannelClosed once Close has been called
+func (a *Actor) readUntilClosed() (*Message, error) {
+	return a.readInbound(context.Background(), a.closed)
+}
+
+// readInbound - reads the next message which passes the Inbound interceptors, the messages they
+// drop are skipped. Returns errReceivedChannelClosed once done is closed.
+func (a *Actor) readInbound(ctx context.Context, done <-chan struct{}) (*Message, error) {
+
+	for {
+		var m *Message
+		var ok bool
+
+		select {
+		case m, ok = <-a.received:
+		case <-a.failed:
+			return nil, errReceivedChannelClosed
+		case <-done:
+			return nil, errReceivedChannelClosed
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		msg, err := a.readMessage(m, ok)
+		if err != nil || msg.MsgType == -1 {
+			return msg, err
+		}
+		if msg = a.intercept(ctx, msg); msg != nil {
+			return msg, nil
+		}
+	}
+}
 
-	m, ok := <-a.received
+func (a *Actor) readMessage(m *Message, ok bool) (*Message, error) {
 
-	if !ok {
-		err := errors.New("the received channel has been closed")
+	if !ok || a.isFailed() {
 		//a.logger.Errorf("Actor.Read err: %e", err)
-		return nil, err
+		return nil, errReceivedChannelClosed
 	}
 
 	if m.Err != nil {
 		a.logger.Errorf("%s.Read err: %s", a, m.Err)
 		if !a.config.IsServer {
-			close(a.received)
-			close(a.toWrite)
+			//closing received and toWrite instead would panic the goroutines still dispatching to them
+			a.fail()
 		}
 		return nil, m.Err
 	}
@@ -75,33 +127,15 @@ func (a *Actor) ReadTimed(duration time.Duration) (*Message, error) {
 
 func (a *Actor) ReadTimedTimeoutMessage(duration time.Duration, onTimeoutMessage *Message) (*Message, error) {
 
-	readMsgChan := make(chan *Message, 1)
-	readErrChan := make(chan error, 1)
-
 	ctx, cancel := context.WithTimeout(context.Background(), duration)
 	defer cancel()
 
-	go func() {
-		m, err := a.Read()
-		readMsgChan <- m
-		readErrChan <- err
-	}()
-
-	select {
-	case <-ctx.Done():
-
-		go func() {
-			//requeue the message when the Read task does finally finish
-			msg := <-readMsgChan
-			if msg != nil && a.getStatus() < Closed {
-				a.logger.Debugf("%s.ReadTimed recycling timed-out message %s", a, msg.Data)
-				a.received <- msg
-			}
-		}()
+	msg, err := a.ReadContext(ctx)
+	if err != nil && errors.Is(err, context.DeadlineExceeded) {
 		return onTimeoutMessage, nil
-	case msg := <-readMsgChan:
-		return msg, <-readErrChan
 	}
+
+	return msg, err
 }
 
 func (a *Actor) WriteMessage(msg *Message) error {
@@ -111,46 +145,81 @@ func (a *Actor) WriteMessage(msg *Message) error {
 // Write - writes a  message to the ipc connection.
 // msgType - denotes the type of data being sent. 0 is a reserved type for internal messages and errors.
 func (a *Actor) Write(msgType int, message []byte) error {
+	return a.WriteContext(context.Background(), msgType, message)
+}
 
-	if msgType == 0 {
-		err := errors.New("message type 0 is reserved")
-		a.logger.Errorf("%s.Write err: %s", a, err)
-		return err
-	}
+// WriteContext - writes a message to the ipc connection, abandoning the write when ctx is
+// cancelled while waiting for the connection or for the writer to accept the message
+func (a *Actor) WriteContext(ctx context.Context, msgType int, message []byte) error {
 
-	status := a.getStatus()
-
-	if a.config.IsServer && status == Listening {
-		time.Sleep(time.Millisecond * 2)
-		a.logger.Infoln("Server is still listening so lets use recursion")
-		//it's possible the client hasn't connected yet so retry it
-		return a.Write(msgType, message)
-	} else if !a.config.IsServer && status == Connecting {
-		a.logger.Infoln("Client is still connecting so lets use recursion")
-		time.Sleep(time.Millisecond * 100)
-		return a.Write(msgType, message)
-	} else if status != Connected {
-		err := errors.New(fmt.Sprintf("cannot write under current status: %s", a.Status()))
+	return a.writeMessageContext(ctx, &Message{MsgType: msgType, Data: message})
+}
+
+// writeMessageContext - passes the message through the outbound interceptors before writing it
+func (a *Actor) writeMessageContext(ctx context.Context, msg *Message) error {
+	return a.outboundChain(writeMessage)(ctx, a, msg)
+}
+
+func writeMessage(ctx context.Context, a *Actor, msg *Message) error {
+
+	if msg.MsgType == 0 {
+		err := errors.New("message type 0 is reserved")
 		a.logger.Errorf("%s.Write err: %s", a, err)
 		return err
 	}
 
-	mlen := len(message)
-	if a.config.IsServer {
-		if mlen > a.config.ServerConfig.MaxMsgSize {
-			err := errors.New("message exceeds maximum message length")
+	for {
+		status := a.getStatus()
+
+		var wait time.Duration
+		if a.config.IsServer && status == Listening {
+			//it's possible the client hasn't connected yet so retry it
+			a.logger.Infoln("Server is still listening so lets retry")
+			wait = time.Millisecond * 2
+		} else if !a.config.IsServer && status == Connecting {
+			a.logger.Infoln("Client is still connecting so lets retry")
+			wait = time.Millisecond * 100
+		} else if status != Connected {
+			err := errors.New(fmt.Sprintf("cannot write under current status: %s", a.Status()))
 			a.logger.Errorf("%s.Write err: %s", a, err)
 			return err
+		} else {
+			break
+		}
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return ctx.Err()
 		}
-	} else if mlen > a.clientRef.maxMsgSize {
+	}
+
+	if msg.segment == nil && msg.fds == nil && len(msg.Data) > a.maxMsgSize() && len(msg.Data) <= a.maxFragmentedMsgSize() {
+		return a.writeFragments(ctx, msg)
+	} else if msg.dataLen() > a.maxMsgSize() {
 		err := errors.New("message exceeds maximum message length")
 		a.logger.Errorf("%s.Write err: %s", a, err)
 		return err
 	}
 
-	a.toWrite <- &Message{MsgType: msgType, Data: message}
+	select {
+	case a.toWrite <- msg:
+		return nil
+	case <-a.failed:
+		return ErrConnectionLost
+	case <-a.closed:
+		return ErrConnectionLost
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
 
-	return nil
+// maxMsgSize - the ServerConfig.MaxMsgSize of a server or the size negotiated in the handshake of a client
+func (a *Actor) maxMsgSize() int {
+	if a.config.IsServer {
+		return a.config.ServerConfig.MaxMsgSize
+	}
+	return a.clientRef.maxMsgSize
 }
 
 func (a *Actor) read(readBytesCb func(*Actor, []byte) bool) {
@@ -180,42 +249,119 @@ func (a *Actor) read(readBytesCb func(*Actor, []byte) bool) {
 			}
 		}
 
-		msgType := bytesToInt(msgRecvd[:4])
-		msgData := msgRecvd[4:]
+		msg, err := decodeFrame(msgRecvd)
+		if err != nil {
+			a.dispatchError(err)
+			continue
+		}
+
+		if msg.flags&flagFDs != 0 {
+			if a.fds != nil {
+				msg.Files = a.fds.take(int(msg.id))
+			}
+			msg.id = 0
+		}
 
-		if msgType == 0 {
+		if msg.flags&flagFragment != 0 {
+			if a.fragments == nil {
+				continue
+			}
+			if msg = a.fragments.reassemble(a, msg); msg == nil {
+				continue
+			}
+		}
+
+		if msg.flags&flagStream != 0 {
+			if a.streams == nil {
+				continue
+			}
+			if streamMsg := a.streams.handle(a, msg); streamMsg != nil {
+				a.receive(streamMsg)
+			}
+		} else if msg.flags&flagReply != 0 {
+			if !a.pending.resolve(msg) {
+				a.logger.Debugf("%s.read - discarding reply %d without a pending request", a, msg.id)
+			}
+		} else if r := a.routes.lookup(msg.MsgType); r != nil {
+			if msg = a.intercept(context.Background(), msg); msg != nil {
+				r.deliver(msg)
+			}
+		} else if msg.flags&flagClose != 0 {
+			a.logger.Debugf("%s.read - discarding close of message type %d without a conn", a, msg.MsgType)
+		} else if msg.MsgType == 0 {
 			//  type 0 = control message
 			a.logger.Debugf("%s.read - control message encountered", a)
 		} else {
-			a.received <- &Message{Data: msgData, MsgType: msgType}
+			a.receive(msg)
 		}
 	}
 }
 
-func (a *Actor) write() {
+// serveConn - runs the read loop of conn and its writer, which stops once the read loop ends. A
+// single writer writes to each connection, so frames written with several syscalls (i.e. file
+// segments) are never interleaved with the frames of a writer of a previous connection.
+func (a *Actor) serveConn(conn net.Conn, readBytesCb func(*Actor, []byte) bool) {
+
+	stop := make(chan struct{})
+	go a.write(conn, stop)
+
+	go func() {
+		a.read(readBytesCb)
+		close(stop)
+	}()
+}
+
+// write - writes the queued messages to conn until stop is closed or the actor is closed or failed
+func (a *Actor) write(conn net.Conn, stop <-chan struct{}) {
 
 	for {
 
-		m, ok := <-a.toWrite
+		var m *Message
+		select {
+		case m = <-a.toWrite:
+		case <-stop:
+			return
+		case <-a.failed:
+			return
+		case <-a.closed:
+			return
+		}
 
-		if !ok {
-			break
+		writer := bufio.NewWriter(conn)
+
+		if m.segment != nil && !a.shouldUseEncryption() {
+			a.writeSegment(conn, writer, m)
+			continue
 		}
 
-		toSend := append(intToBytes(m.MsgType), m.Data...)
-		writer := bufio.NewWriter(a.getConn())
+		toSend, err := encodeFrameSegment(m)
+		if err != nil {
+			a.logger.Errorf("%s error reading file segment: %s", a, err)
+			a.dropMessage(m, err)
+			continue
+		}
 
 		if a.shouldUseEncryption() {
-			var err error
 			toSend, err = encrypt(*a.cipher, toSend)
 			if err != nil {
 				a.dispatchError(err)
+				a.dropMessage(m, err)
 				continue
 			}
 		}
 
+		if m.fds != nil {
+			err = writeWithFDs(conn, append(intToBytes(len(toSend)), toSend...), m.fds)
+			closeFiles(m.fds)
+			if err != nil {
+				a.logger.Errorf("%s error writing message with file descriptors: %s", a, err)
+			}
+			m.reportWritten(err)
+			continue
+		}
+
 		//first send the message size
-		_, err := writer.Write(intToBytes(len(toSend)))
+		_, err = writer.Write(intToBytes(len(toSend)))
 		if err != nil {
 			a.logger.Errorf("%s error writing message size: %s", a, err)
 		}
@@ -229,21 +375,85 @@ func (a *Actor) write() {
 			err = writer.Flush()
 			if err != nil {
 				a.logger.Errorf("%s error flushing data: %s", a, err)
-				continue
 			}
 		}
+		m.reportWritten(err)
 	}
 }
 
+// reportWritten - passes the result of the writer to the caller waiting on the message
+func (m *Message) reportWritten(err error) {
+	if m.written != nil {
+		m.written <- err
+	}
+}
+
+// dropMessage - releases a message the writer could not encode, failing the request waiting on it
+// since no reply will ever be received
+func (a *Actor) dropMessage(m *Message, err error) {
+	closeFiles(m.fds)
+	if m.flags&flagRequest != 0 {
+		a.pending.resolve(&Message{id: m.id, Err: err, MsgType: -1})
+	}
+	m.reportWritten(err)
+}
+
 func (a *Actor) _dispatchStatus(status Status, blocking bool) {
 	a.logger.Debugf("Actor.dispacthStatus(%s): %s", a, status)
 	a.setStatus(status)
+	if status > Connected {
+		a.connectionLost()
+	}
+	if a.hooks != nil {
+		a.hooks.call(status)
+	}
 	if blocking {
-		a.received <- &Message{Status: status.String(), MsgType: -1}
+		a.receive(&Message{Status: status.String(), MsgType: -1})
 	} else {
-		go func() {
-			a.received <- &Message{Status: status.String(), MsgType: -1}
-		}()
+		go a.receive(&Message{Status: status.String(), MsgType: -1})
+	}
+}
+
+// statusHooks - the functions called with each status dispatched by an Actor
+type statusHooks struct {
+	mutex sync.Mutex
+	next  int
+	hooks map[int]func(Status)
+}
+
+func newStatusHooks() *statusHooks {
+	return &statusHooks{hooks: make(map[int]func(Status))}
+}
+
+// onStatus - calls hook with each status dispatched until the returned func is called. hook is
+// called by the goroutine dispatching the status, after the connection loss was handled, and
+// must not block.
+func (a *Actor) onStatus(hook func(Status)) func() {
+
+	h := a.hooks
+	h.mutex.Lock()
+	id := h.next
+	h.next++
+	h.hooks[id] = hook
+	h.mutex.Unlock()
+
+	return func() {
+		h.mutex.Lock()
+		delete(h.hooks, id)
+		h.mutex.Unlock()
+	}
+}
+
+func (h *statusHooks) call(status Status) {
+	h.mutex.Lock()
+	hooks := make([]func(Status), 0, len(h.hooks))
+	for _, hook := range h.hooks {
+		hooks = append(hooks, hook)
+	}
+	h.mutex.Unlock()
+
+	for _, hook := range hooks {
+		hook(status)
 	}
 }
 
@@ -257,7 +467,33 @@ func (a *Actor) dispatchStatus(status Status) {
 
 func (a *Actor) dispatchErrorBlocking(err error) {
 	a.logger.Debugf("Actor.dispacthError(%s): %s", a, err)
-	a.received <- &Message{Err: err, MsgType: -1}
+	a.receive(&Message{Err: err, MsgType: -1})
+}
+
+// receive - passes the message to Read, dropping it once a fatal error was read
+func (a *Actor) receive(msg *Message) {
+	select {
+	case a.received <- msg:
+	case <-a.failed:
+	}
+}
+
+// fail - ends the reads and writes of a client after a fatal error
+func (a *Actor) fail() {
+	if a.failOnce != nil {
+		a.failOnce.Do(func() {
+			close(a.failed)
+		})
+	}
+}
+
+func (a *Actor) isFailed() bool {
+	select {
+	case <-a.failed:
+		return true
+	default:
+		return false
+	}
 }
 
 func (a *Actor) dispatchErrorStrBlocking(err string) {
@@ -317,6 +553,13 @@ func (a *Actor) Close() {
 	a.logger.SetLevel(logrus.FatalLevel)
 
 	a.setStatus(Closing)
+	a.connectionLost()
+
+	if a.closeOnce != nil {
+		a.closeOnce.Do(func() {
+			close(a.closed)
+		})
+	}
 
 	if a.conn != nil {
 		a.getConn().Close()
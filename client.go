@@ -5,21 +5,27 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"time"
 )
 
 // StartClient - start the ipc client.
 // ipcName = is the name of the unix socket or named pipe that the client will try and connect to.
 func StartClient(config *ClientConfig) (*Client, error) {
+	return StartClientContext(context.Background(), config)
+}
+
+// StartClientContext - start the ipc client, abandoning the dial loop when ctx is cancelled
+func StartClientContext(ctx context.Context, config *ClientConfig) (*Client, error) {
 	if config.MultiClient {
-		return StartClientPool(config)
+		return startClientPool(ctx, config)
 	} else {
 		cc, err := NewClient(config.Name, config)
 		if err != nil {
 			return nil, err
 		}
 		cc.ClientId = 0
-		return start(cc)
+		return start(ctx, cc)
 	}
 }
 
@@ -59,43 +65,58 @@ func NewClient(name string, config *ClientConfig) (*Client, error) {
 	return cc, err
 }
 
-func start(c *Client) (*Client, error) {
+func start(ctx context.Context, c *Client) (*Client, error) {
 	c.dispatchStatus(Connecting)
 
-	err := c.dial()
+	err := c.dial(ctx)
 	if err != nil {
 		c.dispatchError(err)
 		return c, err
 	}
 
-	go c.read(c.ByteReader)
-	go c.write()
+	c.serveConn(c.getConn(), c.ByteReader)
 	c.dispatchStatus(Connected)
 
 	return c, nil
 }
 
 // Client connect to the unix socket created by the server -  for unix and linux
-func (c *Client) dial() error {
+func (c *Client) dial(parent context.Context) error {
+
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if c.timeout != 0 {
+		ctx, cancel = context.WithTimeout(parent, c.timeout)
+	} else {
+		ctx, cancel = context.WithCancel(parent)
+	}
+	defer cancel()
 
 	errChan := make(chan error, 1)
 
 	go func() {
-		startTime := time.Now()
 		for {
-			if c.timeout != 0 {
-				if time.Since(startTime) > c.timeout {
-					return
-				}
+			if ctx.Err() != nil {
+				return
 			}
-			conn, err := c.connect()
+			conn, err := c.connectContext(ctx)
 			if err != nil {
+				if !isDialRetryable(err) {
+					//dialing again would fail the same way, the error is dispatched once by the caller
+					errChan <- err
+					return
+				}
 				c.logger.Debugf("Client.dial err: %s", err)
 			} else {
+				if ctx.Err() != nil {
+					conn.Close()
+					return
+				}
 				c.setConn(conn)
-				err = c.handshake()
+				err = c.handshakeContext(ctx, conn)
 				if err != nil {
 					c.logger.Errorf("%s.dial handshake err: %s", c, err)
+					conn.Close()
 				}
 
 				errChan <- err
@@ -106,23 +127,59 @@ func (c *Client) dial() error {
 		}
 	}()
 
-	if c.timeout != 0 {
-		ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
-		defer cancel()
-		select {
-		case <-ctx.Done():
-			return errors.New("timed out trying to connect")
-		case err := <-errChan:
+	select {
+	case <-ctx.Done():
+	case <-c.closed:
+		//stops the reconnect loop of a closed client
+		return errors.New("client has been closed")
+	case err := <-errChan:
+		if !errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, context.Canceled) {
 			return err
 		}
-	} else {
-		return <-errChan
+		//the handshake was abandoned because ctx is done, or about to be when its deadline was reached
+		<-ctx.Done()
 	}
+
+	if parent.Err() != nil {
+		return parent.Err()
+	}
+	return errors.New("timed out trying to connect")
+}
+
+// handshakeContext - the handshake of conn, which is closed when ctx is done first so the
+// handshake doesn't outlive the dial
+func (c *Client) handshakeContext(ctx context.Context, conn net.Conn) error {
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+		defer conn.SetDeadline(time.Time{})
+	}
+	stop := context.AfterFunc(ctx, func() {
+		conn.Close()
+	})
+
+	err := c.handshake()
+	if !stop() {
+		return ctx.Err()
+	} else if deadline, ok := ctx.Deadline(); ok && err != nil && !time.Now().Before(deadline) {
+		return context.DeadlineExceeded
+	}
+
+	return err
+}
+
+func (c *Client) connect() (net.Conn, error) {
+	return c.connectContext(context.Background())
+}
+
+// connectContext - dials the server using the configured Transport
+func (c *Client) connectContext(ctx context.Context) (net.Conn, error) {
+	return c.getTransport().Dial(ctx, c.config.ClientConfig.Name, c.ClientId)
 }
 
 func (c *Client) ByteReader(a *Actor, buff []byte) bool {
 
-	_, err := io.ReadFull(a.getConn(), buff)
+	_, err := io.ReadFull(a.connReader(a.getConn()), buff)
 	if err != nil {
 		a.logger.Debugf("%s.readData err: %s", c, err)
 		if c.getStatus() == Closing {
@@ -131,16 +188,12 @@ func (c *Client) ByteReader(a *Actor, buff []byte) bool {
 			return false
 		}
 
-		if err == io.EOF { // the connection has been closed by the client.
-			a.getConn().Close()
+		// the connection has been closed or reset (i.e. the server process was killed)
+		a.getConn().Close()
 
-			if a.getStatus() != Closing {
-				go reconnect(c)
-			}
-			return false
+		if a.getStatus() != Closing {
+			go reconnect(c)
 		}
-
-		// other read error
 		return false
 	}
 
@@ -155,12 +208,14 @@ func reconnect(c *Client) {
 	// IMPORTANT removing this line will allow a dial before the new connection
 	// is ready resulting in a dial hang when a timeout is not specified
 	time.Sleep(c.retryTimer)
-	err := c.dial()
+	err := c.dial(context.Background())
 	if err != nil {
 		c.logger.Errorf("Client.reconnect -> dial err: %s", err)
 		if err.Error() == "timed out trying to connect" {
 			c.dispatchStatusBlocking(Timeout)
 			c.dispatchErrorStrBlocking("timed out trying to re-connect")
+		} else if c.getStatus() < Closing && !isDialRetryable(err) {
+			c.dispatchErrorBlocking(err)
 		}
 
 		return
@@ -168,7 +223,7 @@ func reconnect(c *Client) {
 
 	c.dispatchStatus(Connected)
 
-	go c.read(c.ByteReader)
+	c.serveConn(c.getConn(), c.ByteReader)
 }
 
 // getStatus - get the current status of the connection
@@ -0,0 +1,238 @@
+package gipc
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// ErrUnregisteredType - returned when a value or message type has not been registered with the Registry
+var ErrUnregisteredType = errors.New("type is not registered")
+
+// Codec - marshals the values written with WriteValue and unmarshals the values read with ReadValue
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// JSONCodec - encodes values with encoding/json (default)
+type JSONCodec struct{}
+
+func (JSONCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (JSONCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+// GobCodec - encodes values with encoding/gob, every message carries its own type information
+type GobCodec struct{}
+
+func (GobCodec) Marshal(v any) ([]byte, error) {
+	var buff bytes.Buffer
+	err := gob.NewEncoder(&buff).Encode(v)
+	return buff.Bytes(), err
+}
+
+func (GobCodec) Unmarshal(data []byte, v any) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// Registry - maps each MsgType to the Go type carried by its messages
+type Registry struct {
+	mutex    sync.RWMutex
+	types    map[int]reflect.Type
+	msgTypes map[reflect.Type]int
+}
+
+// DefaultRegistry - used when the ServerConfig or ClientConfig does not provide a Registry
+var DefaultRegistry = NewRegistry()
+
+func NewRegistry() *Registry {
+	return &Registry{
+		types:    make(map[int]reflect.Type),
+		msgTypes: make(map[reflect.Type]int),
+	}
+}
+
+// RegisterType - registers the type of v for msgType with the DefaultRegistry
+func RegisterType(msgType int, v any) error {
+	return DefaultRegistry.Register(msgType, v)
+}
+
+func baseType(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Pointer {
+		return t.Elem()
+	}
+	return t
+}
+
+// Register - registers the type of v for msgType. Values are read back with the same type as v,
+// so registering &T{} reads *T and registering T{} reads T.
+func (r *Registry) Register(msgType int, v any) error {
+
+	if msgType <= 0 {
+		return fmt.Errorf("cannot register message type %d, message types must be greater than 0", msgType)
+	}
+
+	if v == nil {
+		return errors.New("cannot register a nil value")
+	}
+
+	t := reflect.TypeOf(v)
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if existing, ok := r.types[msgType]; ok && existing != t {
+		return fmt.Errorf("message type %d is already registered to %s", msgType, existing)
+	}
+	if existing, ok := r.msgTypes[baseType(t)]; ok && existing != msgType {
+		return fmt.Errorf("%s is already registered to message type %d", t, existing)
+	}
+
+	r.types[msgType] = t
+	r.msgTypes[baseType(t)] = msgType
+
+	return nil
+}
+
+// MsgType - returns the message type registered for the type of v
+func (r *Registry) MsgType(v any) (int, error) {
+
+	if v == nil {
+		return 0, fmt.Errorf("%w: <nil>", ErrUnregisteredType)
+	}
+
+	r.mutex.RLock()
+	msgType, ok := r.msgTypes[baseType(reflect.TypeOf(v))]
+	r.mutex.RUnlock()
+
+	if !ok {
+		return 0, fmt.Errorf("%w: %T", ErrUnregisteredType, v)
+	}
+
+	return msgType, nil
+}
+
+// New - returns a pointer to a new zero value of the type registered for msgType
+func (r *Registry) New(msgType int) (any, reflect.Type, error) {
+
+	r.mutex.RLock()
+	t, ok := r.types[msgType]
+	r.mutex.RUnlock()
+
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: message type %d", ErrUnregisteredType, msgType)
+	}
+
+	return reflect.New(baseType(t)).Interface(), t, nil
+}
+
+func (a *Actor) getCodec() Codec {
+	var codec Codec
+	if a.config.IsServer {
+		codec = a.config.ServerConfig.Codec
+	} else {
+		codec = a.config.ClientConfig.Codec
+	}
+	if codec == nil {
+		return JSONCodec{}
+	}
+	return codec
+}
+
+func (a *Actor) getRegistry() *Registry {
+	var registry *Registry
+	if a.config.IsServer {
+		registry = a.config.ServerConfig.Registry
+	} else {
+		registry = a.config.ClientConfig.Registry
+	}
+	if registry == nil {
+		return DefaultRegistry
+	}
+	return registry
+}
+
+// WriteValue - marshals v with the Codec and writes it using the message type registered for its type
+func (a *Actor) WriteValue(v any) error {
+	return a.WriteValueContext(context.Background(), v)
+}
+
+// WriteValueContext - WriteValue abandoning the write when ctx is cancelled
+func (a *Actor) WriteValueContext(ctx context.Context, v any) error {
+
+	msgType, data, err := a.encodeValue(v)
+	if err != nil {
+		a.logger.Errorf("%s.WriteValue err: %s", a, err)
+		return err
+	}
+
+	return a.WriteContext(ctx, msgType, data)
+}
+
+func (a *Actor) encodeValue(v any) (int, []byte, error) {
+
+	msgType, err := a.getRegistry().MsgType(v)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	data, err := a.getCodec().Marshal(v)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return msgType, data, nil
+}
+
+// ReadValue - reads the next message skipping status messages, returning the value unmarshalled
+// into the type registered for its MsgType. Unregistered message types return ErrUnregisteredType.
+func (a *Actor) ReadValue() (any, error) {
+	return a.ReadValueContext(context.Background())
+}
+
+// ReadValueContext - ReadValue returning ctx.Err() when ctx is done before a message arrives
+func (a *Actor) ReadValueContext(ctx context.Context) (any, error) {
+
+	for {
+		msg, err := a.ReadContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if msg.MsgType == -1 {
+			continue
+		}
+
+		return a.DecodeValue(msg)
+	}
+}
+
+// DecodeValue - unmarshals the message into the type registered for its MsgType
+func (a *Actor) DecodeValue(msg *Message) (any, error) {
+
+	ptr, t, err := a.getRegistry().New(msg.MsgType)
+	if err != nil {
+		a.logger.Errorf("%s.ReadValue err: %s", a, err)
+		return nil, err
+	}
+
+	err = a.getCodec().Unmarshal(msg.Data, ptr)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.Kind() == reflect.Pointer {
+		return ptr, nil
+	}
+
+	return reflect.ValueOf(ptr).Elem().Interface(), nil
+}
@@ -0,0 +1,117 @@
+package gipc
+
+import (
+	"errors"
+	"testing"
+)
+
+type codecPing struct {
+	Seq  int
+	Note string
+}
+
+type codecPong struct {
+	Seq int
+}
+
+type codecUnknown struct {
+	Value string
+}
+
+func testCodecRoundTrip(t *testing.T, name string, codec Codec) {
+
+	registry := NewRegistry()
+	if err := registry.Register(5, codecPing{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.Register(6, &codecPong{}); err != nil {
+		t.Fatal(err)
+	}
+
+	scon := NewServerConfig(name)
+	scon.Codec = codec
+	scon.Registry = registry
+	sc, err := StartServer(scon)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sc.Close()
+
+	Sleep()
+
+	ccon := NewClientConfig(name)
+	ccon.Codec = codec
+	ccon.Registry = registry
+	cc, err := StartClient(ccon)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+
+	if err = cc.WriteValue(&codecPing{Seq: 1, Note: "hello"}); err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := sc.ReadValue()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ping, ok := v.(codecPing)
+	if !ok || ping.Seq != 1 || ping.Note != "hello" {
+		t.Errorf("Got %#v, Wanted codecPing{Seq: 1, Note: \"hello\"}", v)
+	}
+
+	if err = sc.WriteValue(codecPong{Seq: ping.Seq + 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	v, err = cc.ReadValue()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pong, ok := v.(*codecPong)
+	if !ok || pong.Seq != 2 {
+		t.Errorf("Got %#v, Wanted &codecPong{Seq: 2}", v)
+	}
+
+	if err = cc.WriteValue(codecUnknown{}); !errors.Is(err, ErrUnregisteredType) {
+		t.Errorf("expected ErrUnregisteredType writing an unregistered type but got: %s", err)
+	}
+
+	if err = cc.Write(7, []byte("raw")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = sc.ReadValue(); !errors.Is(err, ErrUnregisteredType) {
+		t.Errorf("expected ErrUnregisteredType reading an unregistered message type but got: %s", err)
+	}
+}
+
+func TestCodecJSON(t *testing.T) {
+	Sleep()
+	testCodecRoundTrip(t, "test_codec_json", JSONCodec{})
+}
+
+func TestCodecGob(t *testing.T) {
+	Sleep()
+	testCodecRoundTrip(t, "test_codec_gob", GobCodec{})
+}
+
+func TestCodecRegistryConflicts(t *testing.T) {
+
+	registry := NewRegistry()
+	if err := registry.Register(5, codecPing{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.Register(5, codecPing{}); err != nil {
+		t.Errorf("registering the same type twice should be allowed: %s", err)
+	}
+	if err := registry.Register(5, codecPong{}); err == nil {
+		t.Error("registering a different type for the same message type should fail")
+	}
+	if err := registry.Register(6, &codecPing{}); err == nil {
+		t.Error("registering the same type for a different message type should fail")
+	}
+	if err := registry.Register(0, codecUnknown{}); err == nil {
+		t.Error("message type 0 is reserved and should fail")
+	}
+}
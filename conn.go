@@ -0,0 +1,183 @@
+package gipc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync/atomic"
+)
+
+// ErrConnectionLost - returned by a conn when the underlying connection is lost (i.e. reconnecting) or closed
+var ErrConnectionLost = errors.New("gipc: the connection was lost")
+
+// connGen - the last conn generation assigned with nextConnGen
+var connGen atomic.Uint32
+
+func nextConnGen() uint32 {
+	return connGen.Add(1)
+}
+
+// errConnWindowExceeded - the remote side of a conn wrote more than the window it was granted
+var errConnWindowExceeded = errors.New("gipc: the remote side of the conn exceeded its window")
+
+// actorConn - a net.Conn framing writes into messages of a single message type and reassembling
+// the data of received messages into a byte stream. Like a Stream it never buffers more than a
+// window of data: the reader grants the writer more once it has read half of it, with a close
+// message carrying the 4 byte increment. readErr is set when the remote side closes the conn or
+// the connection is lost.
+type actorConn struct {
+	*pipe
+	actor   *Actor
+	msgType int
+	gen     uint32 // sent in the id of each message, messages of other generations are dropped (0 accepts all)
+}
+
+type actorAddr struct {
+	name    string
+	msgType int
+}
+
+func (addr actorAddr) Network() string {
+	return "gipc"
+}
+
+func (addr actorAddr) String() string {
+	return fmt.Sprintf("%s/%d", addr.name, addr.msgType)
+}
+
+// AsConn - returns a net.Conn carrying its byte stream in messages of msgType, so third-party
+// protocols can run over the gipc connection. Messages of msgType are no longer returned by Read.
+// A conn doesn't survive a reconnect: it returns ErrConnectionLost and a new conn must be created.
+func (a *Actor) AsConn(msgType int) (net.Conn, error) {
+	return a.asConn(msgType, 0)
+}
+
+// asConn - creates a conn of the generation gen, so messages of a previous conn of msgType which
+// are still in flight (i.e. its close) aren't delivered to it
+func (a *Actor) asConn(msgType int, gen uint32) (*actorConn, error) {
+
+	if msgType <= 0 {
+		return nil, fmt.Errorf("cannot create a conn for message type %d, message types must be greater than 0", msgType)
+	}
+
+	c := newActorConn(a, msgType, gen)
+
+	if !a.routes.add(msgType, c) {
+		return nil, fmt.Errorf("message type %d is already in use by another conn", msgType)
+	}
+
+	return c, nil
+}
+
+func newActorConn(a *Actor, msgType int, gen uint32) *actorConn {
+	return &actorConn{pipe: newPipe(), actor: a, msgType: msgType, gen: gen}
+}
+
+func (c *actorConn) deliver(msg *Message) {
+	if c.gen != 0 && msg.id != c.gen {
+		return
+	}
+
+	if msg.flags&flagClose == 0 {
+		if !c.receive(msg.Data) {
+			c.actor.logger.Errorf("%s.read - failing the conn of message type %d, the remote side exceeded the window", c.actor, c.msgType)
+			c.fail(errConnWindowExceeded)
+		}
+	} else if len(msg.Data) == 4 {
+		c.grant(bytesToInt(msg.Data))
+	} else {
+		//a Write waiting for the window of the closed remote side returns
+		c.fail(io.EOF)
+	}
+}
+
+func (c *actorConn) connectionLost() {
+	c.fail(ErrConnectionLost)
+	c.actor.routes.remove(c.msgType, c)
+}
+
+// fail - ends the reads once the buffered data is read, unless they already end
+func (c *actorConn) fail(err error) {
+	c.mutex.Lock()
+	if c.readErr == nil {
+		c.readErr = err
+	}
+	c.mutex.Unlock()
+	c.wake()
+}
+
+// alive - returns false once the conn is closed or its remote side closed or was lost
+func (c *actorConn) alive() bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return !c.closed && c.readErr == nil
+}
+
+func (c *actorConn) Read(b []byte) (int, error) {
+
+	n, update, err := c.read(b)
+	if update > 0 {
+		c.actor.writeMessageContext(context.Background(), &Message{MsgType: c.msgType, Data: intToBytes(update), flags: flagClose, id: c.gen})
+	}
+
+	return n, err
+}
+
+// Write - blocks while the remote window is exhausted, until the remote side reads the data
+func (c *actorConn) Write(b []byte) (int, error) {
+
+	check := func() error {
+		if c.closed {
+			return net.ErrClosed
+		} else if c.readErr == ErrConnectionLost {
+			return ErrConnectionLost
+		} else if c.sendWindow == 0 && c.readErr != nil {
+			//the remote side closed the conn, it won't read the data
+			return io.ErrClosedPipe
+		}
+		return nil
+	}
+
+	return c.write(b, c.actor.maxMsgSize(), check, func(ctx context.Context, chunk []byte) error {
+		return c.actor.writeMessageContext(ctx, &Message{MsgType: c.msgType, Data: chunk, id: c.gen})
+	})
+}
+
+// Close - closes the conn and notifies the remote conn, which returns io.EOF once its data is read
+func (c *actorConn) Close() error {
+
+	c.mutex.Lock()
+	if c.closed {
+		c.mutex.Unlock()
+		return net.ErrClosed
+	}
+	c.closed = true
+	notify := c.readErr == nil || c.readErr == io.EOF
+	c.mutex.Unlock()
+
+	c.actor.routes.remove(c.msgType, c)
+	c.wake()
+
+	if notify && c.actor.getStatus() == Connected {
+		return c.actor.writeMessageContext(context.Background(), &Message{MsgType: c.msgType, flags: flagClose, id: c.gen})
+	}
+
+	return nil
+}
+
+func (c *actorConn) LocalAddr() net.Addr {
+	return actorAddr{name: c.actor.name(), msgType: c.msgType}
+}
+
+func (c *actorConn) RemoteAddr() net.Addr {
+	return actorAddr{name: c.actor.name(), msgType: c.msgType}
+}
+
+func (a *Actor) name() string {
+	if a.config.IsServer {
+		return a.config.ServerConfig.Name
+	}
+	return a.config.ClientConfig.Name
+}
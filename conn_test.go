@@ -0,0 +1,216 @@
+package gipc
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+// startConnPair - starts a server and a client with the default configs of name and waits until both are connected
+func startConnPair(tb testing.TB, name string) (*Server, *Client) {
+	return startConfiguredPair(tb, NewServerConfig(name), NewClientConfig(name))
+}
+
+// startConfiguredPair - starts a server and a client with the given configs and waits until both are connected
+func startConfiguredPair(tb testing.TB, scon *ServerConfig, ccon *ClientConfig) (*Server, *Client) {
+
+	sc, err := StartServer(scon)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	Sleep()
+
+	cc, err := StartClient(ccon)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	waitForConnected(tb, &sc.Actor)
+	waitForConnected(tb, &cc.Actor)
+
+	return sc, cc
+}
+
+func TestActorConnStream(t *testing.T) {
+
+	Sleep()
+
+	sc, cc := startConnPair(t, "test_conn")
+	defer sc.Close()
+	defer cc.Close()
+
+	sconn, err := sc.AsConn(20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cconn, err := cc.AsConn(20)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = cc.AsConn(20); err == nil {
+		t.Error("a message type should only be used by one conn")
+	}
+
+	//a line based protocol echoing each line back in upper case
+	go func() {
+		reader := bufio.NewReader(sconn)
+		for {
+			line, err := reader.ReadBytes('\n')
+			if err != nil {
+				sconn.Close()
+				return
+			}
+			sconn.Write(bytes.ToUpper(line))
+		}
+	}()
+
+	//larger than the maximum message size so the write is split into several messages
+	large := bytes.Repeat([]byte("a"), MAX_MSG_SIZE+10)
+	large = append(large, '\n')
+
+	go func() {
+		cconn.Write([]byte("hello\n"))
+		cconn.Write(large)
+	}()
+
+	reader := bufio.NewReader(cconn)
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line != "HELLO\n" {
+		t.Errorf("Got %q, Wanted %q", line, "HELLO\n")
+	}
+
+	echoed, err := reader.ReadBytes('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(echoed, bytes.ToUpper(large)) {
+		t.Errorf("Got %d bytes, Wanted %d bytes", len(echoed), len(large))
+	}
+
+	cconn.SetReadDeadline(time.Now().Add(time.Millisecond * 20))
+	if _, err = cconn.Read(make([]byte, 1)); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("expected os.ErrDeadlineExceeded but got: %v", err)
+	}
+	if netErr, ok := err.(net.Error); !ok || !netErr.Timeout() {
+		t.Error("the deadline error should be a net.Error timeout")
+	}
+	cconn.SetReadDeadline(time.Time{})
+
+	//closing the write side makes the server close its conn
+	sconnClosed := make(chan error, 1)
+	go func() {
+		_, err := cconn.Read(make([]byte, 1))
+		sconnClosed <- err
+	}()
+	cc.Write(30, []byte("not for the conn"))
+	cconn.Write([]byte("no newline"))
+	sconn.Close()
+
+	if err = <-sconnClosed; err != io.EOF {
+		t.Errorf("expected io.EOF after the remote close but got: %v", err)
+	}
+
+	m, err := sc.Read()
+	if err != nil || m.MsgType != 30 {
+		t.Errorf("other message types should still be read, got: %v %v", m, err)
+	}
+}
+
+func TestActorConnLost(t *testing.T) {
+
+	Sleep()
+
+	sc, cc := startConnPair(t, "test_conn_lost")
+	defer cc.Close()
+
+	cconn, err := cc.AsConn(20)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	readErr := make(chan error, 1)
+	go func() {
+		_, err := cconn.Read(make([]byte, 1))
+		readErr <- err
+	}()
+
+	sc.Close()
+
+	if err = <-readErr; err != ErrConnectionLost {
+		t.Errorf("expected ErrConnectionLost but got: %v", err)
+	}
+
+	if _, err = cconn.Write([]byte("x")); err != ErrConnectionLost {
+		t.Errorf("expected ErrConnectionLost but got: %v", err)
+	}
+
+	if err = cconn.Close(); err != nil {
+		t.Error(err)
+	}
+	if _, err = cconn.Read(make([]byte, 1)); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("expected net.ErrClosed but got: %v", err)
+	}
+}
+
+func TestActorConnWindow(t *testing.T) {
+
+	Sleep()
+
+	sc, cc := startConnPair(t, "test_conn_window")
+	defer sc.Close()
+	defer cc.Close()
+
+	sconn, err := sc.asConn(20, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cconn, err := cc.AsConn(20)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := bytes.Repeat([]byte("0123456789"), streamInitialWindow/2)
+
+	written := make(chan error, 1)
+	go func() {
+		_, err := cconn.Write(data)
+		written <- err
+	}()
+
+	//the writer stops once the window is exhausted instead of filling the buffer of the reader
+	select {
+	case err = <-written:
+		t.Fatalf("the write should wait for the reader, got: %v", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	sconn.mutex.Lock()
+	buffered := len(sconn.buff)
+	sconn.mutex.Unlock()
+	if buffered != streamInitialWindow {
+		t.Errorf("Got %d buffered bytes, Wanted %d", buffered, streamInitialWindow)
+	}
+
+	//reading grants the writer more window until all of the data is sent
+	received := make([]byte, len(data))
+	if _, err = io.ReadFull(sconn, received); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(received, data) {
+		t.Error("the data read should match the data written")
+	}
+
+	if err = <-written; err != nil {
+		t.Error(err)
+	}
+}
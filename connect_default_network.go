@@ -0,0 +1,7 @@
+//go:build network
+
+package gipc
+
+func defaultTransport() Transport {
+	return &NetworkTransport{}
+}
@@ -1,14 +1,20 @@
-//go:build network
-
 package gipc
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"os"
 	"strconv"
 )
 
+// NetworkTransport - connects the server and client over tcp
+type NetworkTransport struct {
+	Network string // the network type (default is tcp)
+	Host    string // the host the connection is bound to (default is GIPC_NETWORK_HOST or 127.0.0.1)
+	Port    int    // the base port, the clientId is added to it (default is GIPC_NETWORK_PORT or 7100)
+}
+
 func GetDefaultPort() int {
 	envVar := os.Getenv("GIPC_NETWORK_PORT")
 	if len(envVar) > 0 {
@@ -36,36 +42,38 @@ func GetDefaultNetworkType() string {
 	return DEFAULT_NETWORK_TYPE
 }
 
-func (c *Client) getHostAddr(clientId int) string {
-
-	port := GetPort(c.config.ClientConfig.Name)
-	return fmt.Sprintf("%s:%d", GetDefaultHost(), port+clientId)
+func (t *NetworkTransport) network() string {
+	if len(t.Network) > 0 {
+		return t.Network
+	}
+	return DEFAULT_NETWORK_TYPE
 }
 
-func (s *Server) getHostAddr(clientId int) string {
+func (t *NetworkTransport) getHostAddr(name string, clientId int) string {
 
-	port := GetPort(s.config.ServerConfig.Name)
-	return fmt.Sprintf("%s:%d", GetDefaultHost(), port+clientId)
-}
-
-func (c *Client) connect() (net.Conn, error) {
+	host := t.Host
+	if len(host) == 0 {
+		host = GetDefaultHost()
+	}
 
-	conn, err := net.Dial(DEFAULT_NETWORK_TYPE, c.getHostAddr(c.ClientId))
-	if err != nil {
-		c.logger.Errorf("Dial error: %s", err)
+	port := t.Port
+	if port == 0 {
+		port = GetPort(name)
 	}
 
-	return conn, err
+	return fmt.Sprintf("%s:%d", host, port+clientId)
 }
 
-func (s *Server) listen(clientId int) error {
+func (t *NetworkTransport) Dial(ctx context.Context, name string, clientId int) (net.Conn, error) {
+	var d net.Dialer
+	return d.DialContext(ctx, t.network(), t.getHostAddr(name, clientId))
+}
 
-	listener, err := net.Listen(DEFAULT_NETWORK_TYPE, s.getHostAddr(clientId))
-	if err != nil {
-		return err
+func (t *NetworkTransport) Listen(name string, clientId int) (net.Listener, error) {
+	addr := t.getHostAddr(name, clientId)
+	listener, err := net.Listen(t.network(), addr)
+	if isAddrInUse(err) {
+		return nil, fmt.Errorf("%w: %s", ErrAddressInUse, addr)
 	}
-
-	s.listener = listener
-
-	return nil
+	return listener, err
 }
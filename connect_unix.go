@@ -1,57 +1,264 @@
-//go:build !windows && !network
+//go:build !windows
 
 package gipc
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net"
 	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
 	"strings"
+	"sync"
 	"syscall"
+	"time"
 )
 
+// UnixTransport - connects the server and client over a unix domain socket
+type UnixTransport struct {
+	UnmaskPermissions bool        // make the socket writeable for other users
+	Abstract          bool        // use a linux abstract namespace socket (@name) instead of a socket file
+	Dir               string      // the directory of the socket (default is $XDG_RUNTIME_DIR when set, otherwise /tmp/)
+	Path              string      // the absolute path of the socket, overrides Dir
+	Mode              os.FileMode // the permissions of the socket file (default is 0, the umask applies)
+	UID               *int        // the owner of the socket file, 0 is root (default is nil, unchanged)
+	GID               *int        // the group of the socket file, 0 is root (default is nil, unchanged)
+}
+
+// dialRetryableErrors - the server is not listening yet (or is restarting) on a unix socket or tcp,
+// or the backlog of its unix socket is full (EAGAIN on linux)
+var dialRetryableErrors = []error{syscall.ECONNREFUSED, syscall.ENOENT, syscall.EAGAIN}
+
+// addrInUseErrors - another server is listening on the abstract socket or the tcp port
+var addrInUseErrors = []error{syscall.EADDRINUSE}
+
+func nativeTransport() Transport {
+	return &UnixTransport{}
+}
+
 func getSocketName(clientId int, name string) string {
+	return getSocketPath(socketDir(""), clientId, name)
+}
+
+func getSocketPath(dir string, clientId int, name string) string {
 	if clientId > 0 {
-		return fmt.Sprintf("%s%s%d%s", SOCKET_NAME_BASE, name, clientId, SOCKET_NAME_EXT)
+		return filepath.Join(dir, fmt.Sprintf("%s%d%s", name, clientId, SOCKET_NAME_EXT))
 	} else {
-		return fmt.Sprintf("%s%s%s", SOCKET_NAME_BASE, name, SOCKET_NAME_EXT)
+		return filepath.Join(dir, name+SOCKET_NAME_EXT)
 	}
 }
 
-func (c *Client) connect() (net.Conn, error) {
+// socketDir - dir, otherwise the per-user runtime directory so users of a host don't share names
+func socketDir(dir string) string {
+	if dir != "" {
+		return dir
+	}
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+		return runtimeDir
+	}
+	return SOCKET_NAME_BASE
+}
 
-	conn, err := net.Dial("unix", getSocketName(c.ClientId, c.config.ClientConfig.Name))
-	//connect: no such file or directory happens a lot when the client connection closes under normal circumstances
-	if err != nil && !strings.Contains(err.Error(), "connect: no such file or directory") &&
-		!strings.Contains(err.Error(), "connect: connection refused") {
-		c.dispatchError(err)
+// maxSocketPathLen - the size of sun_path, which also holds the terminating null byte
+func maxSocketPathLen() int {
+	if runtime.GOOS == "linux" {
+		return 108
 	}
+	return 104
+}
 
-	return conn, err
+// socketName - the abstract address has no directory or extension and no file is created for it.
+// Abstract sockets have no permissions, any process in the same network namespace can connect.
+func (t *UnixTransport) socketName(clientId int, name string) (string, error) {
+	if !t.Abstract {
+		return t.socketPath(clientId, name)
+	}
+	if runtime.GOOS != "linux" {
+		return "", errors.New("abstract unix sockets are only supported on linux")
+	}
+	if clientId > 0 {
+		return fmt.Sprintf("@%s%d", name, clientId), nil
+	}
+	return "@" + name, nil
 }
 
-func (s *Server) listen(clientId int) error {
+// socketPath - the sockets of the pool manager and pool clients are created next to Path, with the
+// suffix of their name added to its file name
+func (t *UnixTransport) socketPath(clientId int, name string) (string, error) {
 
-	socketName := getSocketName(clientId, s.config.ServerConfig.Name)
+	var path string
+	if t.Path != "" {
+		if !filepath.IsAbs(t.Path) {
+			return "", fmt.Errorf("socket path %q is not absolute", t.Path)
+		}
+		ext := filepath.Ext(t.Path)
+		path = strings.TrimSuffix(t.Path, ext)
+		if strings.HasSuffix(name, poolManagerSuffix) {
+			path += poolManagerSuffix
+		}
+		if clientId > 0 {
+			path += strconv.Itoa(clientId)
+		}
+		path += ext
+	} else {
+		path = getSocketPath(socketDir(t.Dir), clientId, name)
+	}
 
-	if err := os.RemoveAll(socketName); err != nil {
-		return err
+	if len(path) >= maxSocketPathLen() {
+		return "", fmt.Errorf("socket path %q exceeds the %d byte limit of unix sockets", path, maxSocketPathLen()-1)
 	}
 
-	var oldUmask int
-	if s.config.ServerConfig.UnmaskPermissions {
-		oldUmask = syscall.Umask(0)
+	return path, nil
+}
+
+func (t *UnixTransport) withConfig(ac *ActorConfig) Transport {
+	tc := *t
+	dir, path := ac.socketLocation()
+	if tc.Dir == "" {
+		tc.Dir = dir
+	}
+	if tc.Path == "" {
+		tc.Path = path
+	}
+	if ac.IsServer {
+		sc := ac.ServerConfig
+		if sc.UnmaskPermissions {
+			tc.UnmaskPermissions = true
+		}
+		if tc.Mode == 0 {
+			tc.Mode = sc.SocketMode
+		}
+		if tc.UID == nil {
+			tc.UID = sc.SocketUID
+		}
+		if tc.GID == nil {
+			tc.GID = sc.SocketGID
+		}
+	}
+	return &tc
+}
+
+func (t *UnixTransport) Dial(ctx context.Context, name string, clientId int) (net.Conn, error) {
+	socketName, err := t.socketName(clientId, name)
+	if err != nil {
+		return nil, err
+	}
+	var d net.Dialer
+	return d.DialContext(ctx, "unix", socketName)
+}
+
+func (t *UnixTransport) Listen(name string, clientId int) (net.Listener, error) {
+
+	socketName, err := t.socketName(clientId, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.Abstract {
+		//the kernel releases the name once the listener is closed
+		listener, err := net.Listen("unix", socketName)
+		if isAddrInUse(err) {
+			return nil, fmt.Errorf("%w: %s", ErrAddressInUse, socketName)
+		}
+		return listener, err
+	}
+
+	lock, err := lockSocket(socketName)
+	if errors.Is(err, ErrAddressInUse) {
+		return nil, fmt.Errorf("%w: %s", ErrAddressInUse, socketName)
+	} else if err != nil {
+		return nil, err
+	}
+
+	listener, err := t.listen(socketName)
+	if err != nil {
+		if lock != nil {
+			unlockSocket(lock)
+		}
+		return nil, err
+	}
+
+	if lock == nil {
+		return listener, nil
+	}
+
+	return &lockedListener{Listener: listener, lock: lock}, nil
+}
+
+// listen - only removes a stale socket, a server which doesn't hold the lock (i.e. an older version)
+// is detected by dialing it
+func (t *UnixTransport) listen(socketName string) (net.Listener, error) {
+
+	conn, err := net.DialTimeout("unix", socketName, time.Second)
+	if err == nil {
+		conn.Close()
+		return nil, fmt.Errorf("%w: %s", ErrAddressInUse, socketName)
+	}
+
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		if err = os.Remove(socketName); err != nil {
+			return nil, err
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
 	}
 
 	listener, err := net.Listen("unix", socketName)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	if err = t.setPermissions(socketName); err != nil {
+		listener.Close()
+		return nil, err
+	}
+
+	return listener, nil
+}
+
+// lockedListener - releases the lock of the socket once the listener is closed
+type lockedListener struct {
+	net.Listener
+	lock *os.File
+	once sync.Once
+}
+
+func (l *lockedListener) Close() error {
+	err := l.Listener.Close()
+	l.once.Do(func() {
+		unlockSocket(l.lock)
+	})
+	return err
+}
+
+// setPermissions - applied to the socket file once it is created instead of changing the umask,
+// which is shared by every goroutine of the process
+func (t *UnixTransport) setPermissions(socketName string) error {
+
+	mode := t.Mode
+	if mode == 0 && t.UnmaskPermissions {
+		mode = 0777
 	}
 
-	s.listener = listener
+	if t.UID != nil || t.GID != nil {
+		//-1 leaves the owner or group unchanged
+		uid, gid := -1, -1
+		if t.UID != nil {
+			uid = *t.UID
+		}
+		if t.GID != nil {
+			gid = *t.GID
+		}
+		if err := os.Chown(socketName, uid, gid); err != nil {
+			return err
+		}
+	}
 
-	if s.config.ServerConfig.UnmaskPermissions {
-		syscall.Umask(oldUmask)
+	if mode != 0 {
+		return os.Chmod(socketName, mode)
 	}
 
 	return nil
@@ -1,14 +1,46 @@
-//go:build windows && !network
+//go:build windows
 
 package gipc
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/Microsoft/go-winio"
 	"net"
-	"strings"
+	"syscall"
 )
 
+// PipeTransport - connects the server and client over a windows named pipe
+type PipeTransport struct {
+	UnmaskPermissions bool // allow any authenticated user to access the pipe
+}
+
+// dialRetryableErrors - the server is not listening yet (or is restarting) on a named pipe or tcp
+var dialRetryableErrors = []error{
+	syscall.ERROR_FILE_NOT_FOUND,
+	syscall.Errno(231),   // ERROR_PIPE_BUSY
+	syscall.Errno(10061), // WSAECONNREFUSED, "No connection could be made because the target machine actively refused it"
+}
+
+// addrInUseErrors - another server is listening on the tcp port, syscall.EADDRINUSE doesn't match
+// the winsock error
+var addrInUseErrors = []error{
+	syscall.Errno(10048), // WSAEADDRINUSE, "Only one usage of each socket address is normally permitted"
+}
+
+// listenInUseErrors - creating the first instance of a pipe which another server already created
+// fails with ERROR_ACCESS_DENIED
+var listenInUseErrors = []error{
+	syscall.ERROR_ACCESS_DENIED,
+	syscall.ERROR_ALREADY_EXISTS,
+	syscall.Errno(231), // ERROR_PIPE_BUSY
+}
+
+func nativeTransport() Transport {
+	return &PipeTransport{}
+}
+
 func getSocketName(clientId int, name string) string {
 	if clientId > 0 {
 		return fmt.Sprintf("%s%s%d", `\\.\pipe\`, name, clientId)
@@ -17,32 +49,31 @@ func getSocketName(clientId int, name string) string {
 	}
 }
 
-func (c *Client) connect() (net.Conn, error) {
-
-	conn, err := winio.DialPipe(getSocketName(c.ClientId, c.config.ClientConfig.Name), nil)
-
-	if err != nil && !strings.Contains(err.Error(), "the system cannot find the file specified.") {
-		c.dispatchError(err)
+func (t *PipeTransport) withConfig(ac *ActorConfig) Transport {
+	tc := *t
+	if ac.IsServer && ac.ServerConfig.UnmaskPermissions {
+		tc.UnmaskPermissions = true
 	}
-
-	return conn, err
+	return &tc
 }
 
-func (s *Server) listen(clientId int) error {
+func (t *PipeTransport) Dial(ctx context.Context, name string, clientId int) (net.Conn, error) {
+	return winio.DialPipeContext(ctx, getSocketName(clientId, name))
+}
 
-	socketName := getSocketName(clientId, s.config.ServerConfig.Name)
+func (t *PipeTransport) Listen(name string, clientId int) (net.Listener, error) {
 
 	var config *winio.PipeConfig
-	if s.config.ServerConfig.UnmaskPermissions {
+	if t.UnmaskPermissions {
 		config = &winio.PipeConfig{SecurityDescriptor: "D:P(A;;GA;;;AU)"}
 	}
 
-	listener, err := winio.ListenPipe(socketName, config)
-	if err != nil {
-		return err
+	pipe := getSocketName(clientId, name)
+	listener, err := winio.ListenPipe(pipe, config)
+	for _, target := range listenInUseErrors {
+		if errors.Is(err, target) {
+			return nil, fmt.Errorf("%w: %s", ErrAddressInUse, pipe)
+		}
 	}
-
-	s.listener = listener
-
-	return nil
+	return listener, err
 }
@@ -0,0 +1,102 @@
+package gipc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// ErrFDsNotSupported - returned by WriteFDs when the connection can't carry file descriptors,
+// only the unix socket transport can
+var ErrFDsNotSupported = errors.New("gipc: the connection cannot carry file descriptors")
+
+// maxFDs - the maximum number of file descriptors attached to a message (SCM_MAX_FD on linux)
+const maxFDs = 253
+
+// fdQueue - the file descriptors received with the ancillary data of the connection, in the order
+// of the messages they are attached to
+type fdQueue struct {
+	mutex sync.Mutex
+	files []*os.File
+	oob   []byte // the ancillary data buffer, only used by the read loop
+}
+
+func newFDQueue() *fdQueue {
+	return &fdQueue{}
+}
+
+func (q *fdQueue) push(files []*os.File) {
+	q.mutex.Lock()
+	q.files = append(q.files, files...)
+	q.mutex.Unlock()
+}
+
+// take - removes the first n file descriptors, returns nil if less than n were received
+func (q *fdQueue) take(n int) []*os.File {
+	q.mutex.Lock()
+	files := q.files
+	if len(files) < n {
+		//the descriptors were truncated, those received don't belong to the next message either
+		q.files = nil
+		q.mutex.Unlock()
+		closeFiles(files)
+		return nil
+	}
+	q.files = files[n:]
+	q.mutex.Unlock()
+
+	return files[:n:n]
+}
+
+// reset - closes the file descriptors which weren't attached to a message when the connection is lost
+func (q *fdQueue) reset() {
+	q.mutex.Lock()
+	files := q.files
+	q.files = nil
+	q.mutex.Unlock()
+
+	closeFiles(files)
+}
+
+func closeFiles(files []*os.File) {
+	for _, f := range files {
+		f.Close()
+	}
+}
+
+// WriteFDs - writes a message with the file descriptors of fds attached, which are received in
+// Message.Files as new descriptors referring to the same open files. fds can be closed once
+// WriteFDs returns, which waits until the message was written and returns the error of the writer.
+// Returns ErrFDsNotSupported unless the connection is a unix socket.
+func (a *Actor) WriteFDs(msgType int, data []byte, fds ...*os.File) error {
+
+	if len(fds) > maxFDs {
+		err := fmt.Errorf("cannot attach more than %d file descriptors to a message", maxFDs)
+		a.logger.Errorf("%s.WriteFDs err: %s", a, err)
+		return err
+	}
+
+	//the connection is checked again by the writer when the actor isn't connected yet
+	if conn := a.getConn(); conn != nil && !canCarryFDs(conn) {
+		a.logger.Errorf("%s.WriteFDs err: %s", a, ErrFDsNotSupported)
+		return ErrFDsNotSupported
+	}
+
+	//the writer sends duplicates so the caller can close fds before the message is written
+	dups, err := dupFiles(fds)
+	if err != nil {
+		return err
+	}
+
+	written := make(chan error, 1)
+	err = a.writeMessageContext(context.Background(), &Message{MsgType: msgType, Data: data, fds: dups, flags: flagFDs, id: uint32(len(dups)), written: written})
+	if err != nil {
+		closeFiles(dups)
+		return err
+	}
+
+	//the descriptors are only attached once the writer sends the message, so its error is reported
+	return <-written
+}
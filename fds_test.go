@@ -0,0 +1,211 @@
+//go:build !windows && !network
+
+package gipc
+
+import (
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestUnixFDs(t *testing.T) {
+
+	Sleep()
+
+	sc, cc := startConnPair(t, "test_fds")
+	defer sc.Close()
+	defer cc.Close()
+
+	path := filepath.Join(t.TempDir(), "shared")
+	if err := os.WriteFile(path, []byte("shared content"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pr.Close()
+
+	if err = cc.WriteFDs(5, []byte("files"), f, pw); err != nil {
+		t.Fatal(err)
+	}
+	//the descriptors can be closed as soon as WriteFDs returns
+	f.Close()
+	pw.Close()
+
+	if err = cc.Write(6, []byte("no files")); err != nil {
+		t.Fatal(err)
+	}
+	if err = cc.WriteFDs(7, nil, pr); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := sc.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.MsgType != 5 || string(m.Data) != "files" || len(m.Files) != 2 {
+		t.Fatalf("expected message type 5 with 2 files but got: %+v", m)
+	}
+
+	content, err := io.ReadAll(m.Files[0])
+	if err != nil || string(content) != "shared content" {
+		t.Errorf("Got %q %v, Wanted %q", content, err, "shared content")
+	}
+
+	//the server writes to the pipe created by the client
+	m.Files[1].Write([]byte("through the pipe"))
+	for _, f := range m.Files {
+		f.Close()
+	}
+
+	buff := make([]byte, 16)
+	if _, err = io.ReadFull(pr, buff); err != nil || string(buff) != "through the pipe" {
+		t.Errorf("Got %q %v, Wanted %q", buff, err, "through the pipe")
+	}
+
+	m, err = sc.Read()
+	if err != nil || m.MsgType != 6 || m.Files != nil {
+		t.Errorf("expected message type 6 without files but got: %+v %v", m, err)
+	}
+
+	m, err = sc.Read()
+	if err != nil || m.MsgType != 7 || len(m.Files) != 1 {
+		t.Fatalf("expected message type 7 with 1 file but got: %+v %v", m, err)
+	}
+	m.Files[0].Close()
+}
+
+func TestUnixFDsNotSupported(t *testing.T) {
+
+	Sleep()
+
+	sc, cc := startSegmentPair(t, "test_fds_copy", true, copyTransport())
+	defer sc.Close()
+	defer cc.Close()
+
+	f, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if err = cc.WriteFDs(5, nil, f); err != ErrFDsNotSupported {
+		t.Errorf("expected ErrFDsNotSupported but got: %v", err)
+	}
+}
+
+func TestUnixFDsNonBlocking(t *testing.T) {
+
+	Sleep()
+
+	sc, cc := startConnPair(t, "test_fds_nonblocking")
+	defer sc.Close()
+	defer cc.Close()
+
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pr.Close()
+	defer pw.Close()
+
+	if err = cc.WriteFDs(5, nil, pr); err != nil {
+		t.Fatal(err)
+	}
+
+	flags, err := controlFd(pr, func(fd int) (int, error) {
+		r, _, errno := syscall.Syscall(syscall.SYS_FCNTL, uintptr(fd), syscall.F_GETFL, 0)
+		if errno != 0 {
+			return -1, errno
+		}
+		return int(r), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if flags&syscall.O_NONBLOCK == 0 {
+		t.Error("the pipe should remain in non-blocking mode after WriteFDs")
+	}
+
+	m, err := sc.Read()
+	if err != nil || len(m.Files) != 1 {
+		t.Fatalf("expected 1 file but got: %+v %v", m, err)
+	}
+	m.Files[0].Close()
+}
+
+func TestUnixFDsWriteError(t *testing.T) {
+
+	Sleep()
+
+	sc, cc := startConnPair(t, "test_fds_write_error")
+	defer sc.Close()
+	defer cc.Close()
+
+	f, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	//the writer fails once the write side of the connection is shut down underneath it
+	cc.getConn().(*net.UnixConn).CloseWrite()
+	if err = cc.WriteFDs(5, nil, f); err == nil {
+		t.Error("the error of the writer should be returned by WriteFDs")
+	}
+
+	//the descriptors queued without their message are closed when the connection is lost
+	dup, err := dupFiles([]*os.File{f})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sc.fds.push(dup)
+	sc.connectionLost()
+	if files := sc.fds.take(0); len(files) != 0 || len(sc.fds.files) != 0 {
+		t.Error("the file descriptors should be discarded when the connection is lost")
+	}
+	if _, err = dup[0].Stat(); err == nil {
+		t.Error("the discarded file descriptor should be closed")
+	}
+}
+
+func TestUnixFDsCloseOnExec(t *testing.T) {
+
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pr.Close()
+	defer pw.Close()
+
+	dups, err := dupFiles([]*os.File{pr, pw})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeFiles(dups)
+
+	for _, f := range dups {
+		flags, err := controlFd(f, func(fd int) (int, error) {
+			r, _, errno := syscall.Syscall(syscall.SYS_FCNTL, uintptr(fd), syscall.F_GETFD, 0)
+			if errno != 0 {
+				return -1, errno
+			}
+			return int(r), nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if flags&syscall.FD_CLOEXEC == 0 {
+			t.Error("the duplicated descriptors should not be inherited by child processes")
+		}
+	}
+}
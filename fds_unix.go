@@ -0,0 +1,147 @@
+//go:build !windows
+
+package gipc
+
+import (
+	"io"
+	"net"
+	"os"
+	"syscall"
+)
+
+func canCarryFDs(conn net.Conn) bool {
+	_, ok := conn.(*net.UnixConn)
+	return ok
+}
+
+func dupFiles(files []*os.File) ([]*os.File, error) {
+
+	dups := make([]*os.File, 0, len(files))
+	for _, f := range files {
+		fd, err := controlFd(f, dupCloseOnExec)
+		if err != nil {
+			closeFiles(dups)
+			return nil, err
+		}
+		dups = append(dups, os.NewFile(uintptr(fd), f.Name()))
+	}
+
+	return dups, nil
+}
+
+// dupCloseOnExec - duplicates fd with the close-on-exec flag set, the fork lock prevents a child
+// process started meanwhile from inheriting the duplicate
+func dupCloseOnExec(fd int) (int, error) {
+
+	syscall.ForkLock.RLock()
+	defer syscall.ForkLock.RUnlock()
+
+	dup, err := syscall.Dup(fd)
+	if err != nil {
+		return -1, err
+	}
+	syscall.CloseOnExec(dup)
+
+	return dup, nil
+}
+
+// controlFd - calls fn with the descriptor of f, unlike f.Fd() it leaves a non-blocking file
+// (i.e. a pipe or socket) in non-blocking mode
+func controlFd(f *os.File, fn func(fd int) (int, error)) (int, error) {
+
+	rc, err := f.SyscallConn()
+	if err != nil {
+		return -1, err
+	}
+
+	res, fnErr := -1, error(nil)
+	if err = rc.Control(func(fd uintptr) {
+		res, fnErr = fn(int(fd))
+	}); err != nil {
+		return -1, err
+	}
+
+	return res, fnErr
+}
+
+// writeWithFDs - writes b with the file descriptors attached to its first byte
+func writeWithFDs(conn net.Conn, b []byte, files []*os.File) error {
+
+	uc, ok := conn.(*net.UnixConn)
+	if !ok {
+		return ErrFDsNotSupported
+	}
+
+	//the duplicates are only closed once written so their descriptors stay valid
+	fds := make([]int, len(files))
+	for i, f := range files {
+		fd, err := controlFd(f, func(fd int) (int, error) { return fd, nil })
+		if err != nil {
+			return err
+		}
+		fds[i] = fd
+	}
+
+	n, _, err := uc.WriteMsgUnix(b, syscall.UnixRights(fds...), nil)
+	if err != nil {
+		return err
+	}
+
+	//the socket may accept part of the data, the rest is written without the ancillary data
+	_, err = uc.Write(b[n:])
+
+	return err
+}
+
+// fdReader - reads the connection of the actor, queueing the file descriptors received with
+// the ancillary data of unix sockets
+type fdReader struct {
+	conn  *net.UnixConn
+	queue *fdQueue
+}
+
+func (a *Actor) connReader(conn net.Conn) io.Reader {
+	uc, ok := conn.(*net.UnixConn)
+	if !ok || a.fds == nil {
+		return conn
+	}
+	return fdReader{conn: uc, queue: a.fds}
+}
+
+func (r fdReader) Read(b []byte) (int, error) {
+
+	if r.queue.oob == nil {
+		r.queue.oob = make([]byte, syscall.CmsgSpace(maxFDs*4))
+	}
+
+	//when the ancillary data is truncated the kernel closes the remaining descriptors, the
+	//message they are attached to is then read without its Files
+	n, oobn, _, _, err := r.conn.ReadMsgUnix(b, r.queue.oob)
+	if oobn > 0 {
+		r.queue.push(parseUnixRights(r.queue.oob[:oobn]))
+	}
+
+	return n, err
+}
+
+func parseUnixRights(oob []byte) []*os.File {
+
+	msgs, err := syscall.ParseSocketControlMessage(oob)
+	if err != nil {
+		return nil
+	}
+
+	var files []*os.File
+	for _, msg := range msgs {
+		fds, err := syscall.ParseUnixRights(&msg)
+		if err != nil {
+			continue
+		}
+		for _, fd := range fds {
+			syscall.CloseOnExec(fd)
+			files = append(files, os.NewFile(uintptr(fd), "gipc-fd"))
+		}
+	}
+
+	return files
+}
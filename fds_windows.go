@@ -0,0 +1,25 @@
+//go:build windows
+
+package gipc
+
+import (
+	"io"
+	"net"
+	"os"
+)
+
+func canCarryFDs(conn net.Conn) bool {
+	return false
+}
+
+func dupFiles(files []*os.File) ([]*os.File, error) {
+	return nil, ErrFDsNotSupported
+}
+
+func writeWithFDs(conn net.Conn, b []byte, files []*os.File) error {
+	return ErrFDsNotSupported
+}
+
+func (a *Actor) connReader(conn net.Conn) io.Reader {
+	return conn
+}
@@ -0,0 +1,350 @@
+package gipc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	fileChunkSize = 1024 * 1024 // the maximum chunk size, smaller when the maximum message size is smaller
+
+	fileOpOffer = "offer" // announces a file, the reply is the offset to resume from
+	fileOpChunk = "chunk" // the data following the header is written at its offset
+	fileOpDone  = "done"  // the file is verified and moved into the directory
+)
+
+// FileProgressFunc - called after each chunk with the bytes transferred so far out of size
+type FileProgressFunc func(name string, transferred int64, size int64)
+
+// FileReceiver - receives the files sent with SendFile into Dir. Partially received files are kept
+// next to it as hidden .part files so an interrupted transfer resumes from where it stopped, even
+// after a restart. It implements Handler and is registered on a ServeMux for FILE_MSGTYPE.
+type FileReceiver struct {
+	Dir      string
+	Progress FileProgressFunc             // optional, called after each chunk is written
+	Received func(path string, err error) // optional, called when a file is complete or fails verification
+}
+
+// fileHeader - the JSON header prefixing each file transfer request
+type fileHeader struct {
+	Op     string `json:"op"`
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	Hash   string `json:"sha256"`
+	Offset int64  `json:"offset,omitempty"`
+}
+
+// ReceiveFiles - returns a FileReceiver writing files into dir
+func ReceiveFiles(dir string) *FileReceiver {
+	return &FileReceiver{Dir: dir}
+}
+
+// SendFile - sends the file at path to the FileReceiver of the remote side. The file is sent in
+// chunks and verified with its SHA-256 hash by the receiver. When the connection is lost the
+// transfer resumes from the last written chunk once reconnected, until ctx is done.
+func (a *Actor) SendFile(ctx context.Context, path string) error {
+	return a.SendFileProgress(ctx, path, nil)
+}
+
+// SendFileProgress - SendFile calling progress after each chunk is acknowledged
+func (a *Actor) SendFileProgress(ctx context.Context, path string, progress FileProgressFunc) error {
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, f)
+	if err != nil {
+		return err
+	}
+
+	header := fileHeader{
+		Name: filepath.Base(path),
+		Size: size,
+		Hash: hex.EncodeToString(hash.Sum(nil)),
+	}
+
+	for {
+		err = a.sendFile(ctx, f, header, progress)
+		if err == nil || ctx.Err() != nil || !a.isTransferRetryable(err) {
+			return err
+		}
+
+		a.logger.Warnf("%s.SendFile - resuming %s once reconnected: %s", a, header.Name, err)
+		if waitErr := a.waitForReconnect(ctx); waitErr != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+	}
+}
+
+// sendFile - offers the file and sends it from the offset returned by the receiver
+func (a *Actor) sendFile(ctx context.Context, f *os.File, header fileHeader, progress FileProgressFunc) error {
+
+	header.Op = fileOpOffer
+	reply, err := a.fileRequest(ctx, header, nil)
+	if err != nil {
+		return err
+	}
+
+	var offer fileHeader
+	if err = json.Unmarshal(reply.Data, &offer); err != nil {
+		return err
+	}
+
+	header.Op = fileOpChunk
+	for offset := offer.Offset; offset < header.Size; {
+		header.Offset = offset
+		msg, err := encodeFileRequest(header, nil)
+		if err != nil {
+			return err
+		}
+
+		//the chunk fills the room left by the encoded header, which grows with the escaping of the name
+		room := int64(a.maxMsgSize() - len(msg.Data))
+		if room <= 0 {
+			return fmt.Errorf("the file transfer header of %s exceeds the maximum message length", header.Name)
+		}
+		n := min(fileChunkSize, room, header.Size-offset)
+		//the chunk is read from the file by the writer, with sendfile when possible. The request
+		//waits for the writer to report so the file isn't closed while it is read.
+		msg.segment = &fileSegment{file: f, offset: offset, length: int(n)}
+		msg.written = make(chan error, 1)
+
+		if _, err = a.request(ctx, msg); err != nil {
+			return err
+		}
+		offset += n
+
+		if progress != nil {
+			progress(header.Name, offset, header.Size)
+		}
+	}
+
+	header.Op = fileOpDone
+	header.Offset = 0
+	_, err = a.fileRequest(ctx, header, nil)
+
+	return err
+}
+
+// fileRequest - sends the request of header and waits for its reply
+func (a *Actor) fileRequest(ctx context.Context, header fileHeader, data []byte) (*Message, error) {
+
+	msg, err := encodeFileRequest(header, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.request(ctx, msg)
+}
+
+// encodeFileRequest - the payload is the 2 byte header length, the JSON header and the data
+func encodeFileRequest(header fileHeader, data []byte) (*Message, error) {
+
+	encoded, err := json.Marshal(header)
+	if err != nil {
+		return nil, err
+	}
+
+	payload := make([]byte, 2, 2+len(encoded)+len(data))
+	binary.BigEndian.PutUint16(payload, uint16(len(encoded)))
+	payload = append(payload, encoded...)
+	payload = append(payload, data...)
+
+	return &Message{MsgType: FILE_MSGTYPE, Data: payload}, nil
+}
+
+// isTransferRetryable - the request was aborted or couldn't be written because the connection was lost
+func (a *Actor) isTransferRetryable(err error) bool {
+	var remoteErr *RemoteError
+	if errors.As(err, &remoteErr) {
+		return false
+	}
+	return errors.Is(err, ErrRequestAborted) || a.getStatus() != Connected
+}
+
+// waitForReconnect - waits for the connection to be re-established, fails once the actor is closed
+// or the client gave up reconnecting
+func (a *Actor) waitForReconnect(ctx context.Context) error {
+	for {
+		switch status := a.getStatus(); status {
+		case Connected:
+			return nil
+		case Closing, Closed, Timeout, Error:
+			return fmt.Errorf("cannot resume under current status: %s", status)
+		}
+
+		select {
+		case <-time.After(time.Millisecond * 100):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// ServeIPC - handles the file transfer requests of msg
+func (fr *FileReceiver) ServeIPC(ctx context.Context, a *Actor, msg *Message) {
+
+	reply, err := fr.handle(msg.Data)
+
+	if err != nil {
+		a.logger.Errorf("%s.FileReceiver err: %s", a, err)
+		err = a.ReplyError(msg, err)
+	} else {
+		err = a.ReplyContext(ctx, msg, reply)
+	}
+
+	if err != nil {
+		a.logger.Errorf("%s.FileReceiver err: %s", a, err)
+	}
+}
+
+func (fr *FileReceiver) handle(payload []byte) ([]byte, error) {
+
+	if len(payload) < 2 || len(payload) < 2+int(binary.BigEndian.Uint16(payload)) {
+		return nil, errors.New("file transfer request is shorter than its header")
+	}
+	headerLen := 2 + int(binary.BigEndian.Uint16(payload))
+
+	var header fileHeader
+	if err := json.Unmarshal(payload[2:headerLen], &header); err != nil {
+		return nil, err
+	}
+
+	name := filepath.Base(header.Name)
+	if name != header.Name || name == "." || name == ".." || name == string(filepath.Separator) {
+		return nil, fmt.Errorf("invalid file name %q", header.Name)
+	}
+	hash, err := hex.DecodeString(header.Hash)
+	if err != nil || len(hash) != sha256.Size {
+		return nil, fmt.Errorf("invalid sha256 %q", header.Hash)
+	}
+	//the part file is named after the decoded hash, never the text sent by the peer
+	header.Hash = hex.EncodeToString(hash)
+
+	//the hash is part of the name so a changed file with the same name doesn't resume the old one
+	partPath := filepath.Join(fr.Dir, fmt.Sprintf(".%s.%s.part", name, header.Hash))
+	if filepath.Dir(partPath) != filepath.Clean(fr.Dir) {
+		return nil, fmt.Errorf("invalid file name %q", header.Name)
+	}
+
+	switch header.Op {
+	case fileOpOffer:
+		offset, err := fr.openPart(partPath)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(fileHeader{Op: fileOpOffer, Name: name, Size: header.Size, Hash: header.Hash, Offset: offset})
+	case fileOpChunk:
+		return nil, fr.writeChunk(partPath, header, payload[headerLen:])
+	case fileOpDone:
+		return nil, fr.complete(partPath, name, header)
+	}
+
+	return nil, fmt.Errorf("unknown file transfer operation %q", header.Op)
+}
+
+// openPart - creates the part file unless it exists, returns the number of bytes already received
+func (fr *FileReceiver) openPart(partPath string) (int64, error) {
+
+	f, err := os.OpenFile(partPath, os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	return info.Size(), nil
+}
+
+func (fr *FileReceiver) writeChunk(partPath string, header fileHeader, data []byte) error {
+
+	f, err := os.OpenFile(partPath, os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() < header.Offset {
+		return fmt.Errorf("chunk offset %d is beyond the %d bytes received", header.Offset, info.Size())
+	}
+	if header.Offset+int64(len(data)) > header.Size {
+		return errors.New("chunk exceeds the file size")
+	}
+
+	if _, err = f.WriteAt(data, header.Offset); err != nil {
+		return err
+	}
+
+	if fr.Progress != nil {
+		fr.Progress(header.Name, header.Offset+int64(len(data)), header.Size)
+	}
+
+	return nil
+}
+
+// complete - verifies the size and hash of the received file before moving it into Dir
+func (fr *FileReceiver) complete(partPath string, name string, header fileHeader) error {
+
+	path := filepath.Join(fr.Dir, name)
+
+	err := verifyFile(partPath, header)
+	if err == nil {
+		err = os.Rename(partPath, path)
+	} else {
+		//the next attempt starts from zero
+		os.Remove(partPath)
+	}
+
+	if fr.Received != nil {
+		fr.Received(path, err)
+	}
+
+	return err
+}
+
+func verifyFile(path string, header fileHeader) error {
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, f)
+	if err != nil {
+		return err
+	}
+
+	if size != header.Size {
+		return fmt.Errorf("received %d bytes of the %d bytes of %s", size, header.Size, header.Name)
+	} else if hex.EncodeToString(hash.Sum(nil)) != header.Hash {
+		return fmt.Errorf("the sha256 of %s doesn't match", header.Name)
+	}
+
+	return nil
+}
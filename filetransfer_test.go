@@ -0,0 +1,230 @@
+package gipc
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func writeTestFile(t testing.TB, dir string, name string, size int) []byte {
+	data := make([]byte, size)
+	rand.Read(data)
+	if err := os.WriteFile(filepath.Join(dir, name), data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func serveFiles(t *testing.T, name string, fr *FileReceiver) *Server {
+
+	sc, err := StartServer(NewServerConfig(name))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mux := NewServeMux()
+	mux.Handle(FILE_MSGTYPE, fr)
+	go sc.Serve(mux)
+
+	return sc
+}
+
+func TestFileTransfer(t *testing.T) {
+
+	Sleep()
+
+	srcDir, dstDir := t.TempDir(), t.TempDir()
+	data := writeTestFile(t, srcDir, "bundle.tar", MAX_MSG_SIZE+1234)
+	writeTestFile(t, srcDir, "empty", 0)
+
+	received := make(chan error, 2)
+	fr := ReceiveFiles(dstDir)
+	fr.Received = func(path string, err error) {
+		received <- err
+	}
+
+	sc := serveFiles(t, "test_file", fr)
+	defer sc.Close()
+
+	Sleep()
+
+	cc, err := StartClient(NewClientConfig("test_file"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+	drainClient(cc)
+
+	var transferred int64
+	err = cc.SendFileProgress(context.Background(), filepath.Join(srcDir, "bundle.tar"), func(name string, n int64, size int64) {
+		transferred = n
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = <-received; err != nil {
+		t.Fatal(err)
+	}
+	if transferred != int64(len(data)) {
+		t.Errorf("Got progress %d, Wanted %d", transferred, len(data))
+	}
+
+	got, err := os.ReadFile(filepath.Join(dstDir, "bundle.tar"))
+	if err != nil || !bytes.Equal(got, data) {
+		t.Errorf("the received file doesn't match the sent file: %v", err)
+	}
+
+	if err = cc.SendFile(context.Background(), filepath.Join(srcDir, "empty")); err != nil {
+		t.Fatal(err)
+	}
+	<-received
+	if info, err := os.Stat(filepath.Join(dstDir, "empty")); err != nil || info.Size() != 0 {
+		t.Errorf("expected an empty file but got: %v %v", info, err)
+	}
+
+	//only the received files remain, the part files were renamed
+	if entries, _ := os.ReadDir(dstDir); len(entries) != 2 {
+		t.Errorf("Got %d files, Wanted 2", len(entries))
+	}
+
+	//the receiver rejects names escaping its directory
+	var remoteErr *RemoteError
+	header := fileHeader{Op: fileOpOffer, Name: "../escape", Hash: string(bytes.Repeat([]byte("0"), 64))}
+	if _, err = cc.fileRequest(context.Background(), header, nil); !errors.As(err, &remoteErr) {
+		t.Errorf("expected a remote error but got: %v", err)
+	}
+
+	//and hashes escaping it, padded to the length of a hex encoded sha256
+	outside := t.TempDir()
+	traversal := "/../../" + filepath.Base(outside) + "/pwn"
+	traversal = strings.Repeat("/", 64-len(traversal)) + traversal
+	header = fileHeader{Op: fileOpOffer, Name: "escape", Hash: traversal}
+	if _, err = cc.fileRequest(context.Background(), header, nil); !errors.As(err, &remoteErr) {
+		t.Errorf("expected a remote error but got: %v", err)
+	}
+	if entries, _ := os.ReadDir(outside); len(entries) != 0 {
+		t.Errorf("the receiver should not create files outside its directory, got %d", len(entries))
+	}
+}
+
+func TestFileTransferEscapedName(t *testing.T) {
+
+	Sleep()
+
+	//each < of the name takes 6 bytes once the header is encoded
+	name := strings.Repeat("<", 150)
+	srcDir, dstDir := t.TempDir(), t.TempDir()
+	data := writeTestFile(t, srcDir, name, 10000)
+
+	scon := NewServerConfig("test_file_escaped")
+	scon.MaxMsgSize = 4096
+	sc, err := StartServer(scon)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sc.Close()
+
+	mux := NewServeMux()
+	mux.Handle(FILE_MSGTYPE, ReceiveFiles(dstDir))
+	go sc.Serve(mux)
+
+	Sleep()
+
+	cc, err := StartClient(NewClientConfig("test_file_escaped"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err = cc.SendFile(ctx, filepath.Join(srcDir, name)); err != nil {
+		t.Fatal(err)
+	}
+	if received, err := os.ReadFile(filepath.Join(dstDir, name)); err != nil || !bytes.Equal(received, data) {
+		t.Errorf("the received file should match the sent file: %v", err)
+	}
+}
+
+func TestFileTransferResume(t *testing.T) {
+
+	Sleep()
+
+	srcDir, dstDir := t.TempDir(), t.TempDir()
+	data := writeTestFile(t, srcDir, "core.dump", fileChunkSize*4)
+
+	//the first server is closed once the first chunk is written
+	var once sync.Once
+	firstChunk := make(chan struct{})
+	fr := ReceiveFiles(dstDir)
+	fr.Progress = func(name string, n int64, size int64) {
+		once.Do(func() { close(firstChunk) })
+	}
+
+	sc := serveFiles(t, "test_file_resume", fr)
+
+	Sleep()
+
+	cc, err := StartClient(NewClientConfig("test_file_resume"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+	drainClient(cc)
+
+	var mutex sync.Mutex
+	var offsets []int64
+	sent := make(chan error, 1)
+	go func() {
+		sent <- cc.SendFileProgress(context.Background(), filepath.Join(srcDir, "core.dump"), func(name string, n int64, size int64) {
+			mutex.Lock()
+			offsets = append(offsets, n)
+			mutex.Unlock()
+		})
+	}()
+
+	<-firstChunk
+	sc.Close()
+
+	time.Sleep(time.Millisecond * 100)
+
+	received := make(chan error, 1)
+	fr2 := ReceiveFiles(dstDir)
+	fr2.Received = func(path string, err error) {
+		received <- err
+	}
+	sc2 := serveFiles(t, "test_file_resume", fr2)
+	defer sc2.Close()
+
+	if err = <-sent; err != nil {
+		t.Fatal(err)
+	}
+	if err = <-received; err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := os.ReadFile(filepath.Join(dstDir, "core.dump"))
+	if err != nil || !bytes.Equal(got, data) {
+		t.Errorf("the received file doesn't match the sent file: %v", err)
+	}
+
+	//the transfer resumed instead of sending the file again
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(offsets) > len(data)/fileChunkSize || offsets[len(offsets)-1] != int64(len(data)) {
+		t.Errorf("the transfer should have resumed, got progress %v", offsets)
+	}
+	for i := 1; i < len(offsets); i++ {
+		if offsets[i] < offsets[i-1] {
+			t.Errorf("the transfer restarted from zero, got progress %v", offsets)
+		}
+	}
+}
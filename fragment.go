@@ -0,0 +1,176 @@
+package gipc
+
+import (
+	"context"
+	"encoding/binary"
+	"sync"
+	"sync/atomic"
+)
+
+// fragmentTable - the messages being reassembled keyed by fragment set id, the sender assigns
+// a new set id to each fragmented message so concurrent writes can interleave
+type fragmentTable struct {
+	mutex  sync.Mutex
+	sets   map[uint32]*fragmentSet
+	nextId atomic.Uint32
+}
+
+type fragmentSet struct {
+	data      []byte
+	next      uint32 // the index of the next fragment
+	discarded bool   // the set is incomplete or too large, its fragments are dropped
+}
+
+// every fragment is prefixed with the 4 byte fragment set id and the 4 byte index of the fragment
+const fragmentHeaderSize = 8
+
+// fragmentAbortIndex - the index of the final fragment sent without data when the write of a
+// fragmented message is abandoned, so the receiver drops the fragments already sent
+const fragmentAbortIndex = ^uint32(0)
+
+func newFragmentTable() *fragmentTable {
+	return &fragmentTable{sets: make(map[uint32]*fragmentSet)}
+}
+
+// maxFragmentedMsgSize - the MaxFragmentedMsgSize of the config, 0 when fragmentation is disabled
+func (a *Actor) maxFragmentedMsgSize() int {
+	if a.config == nil {
+		return 0
+	} else if a.config.IsServer {
+		return a.config.ServerConfig.MaxFragmentedMsgSize
+	}
+	return a.config.ClientConfig.MaxFragmentedMsgSize
+}
+
+// writeFragments - writes msg as fragments no larger than the maximum message size, the writer
+// accepts each fragment separately so other messages are interleaved between them
+func (a *Actor) writeFragments(ctx context.Context, msg *Message) error {
+
+	setId := a.fragments.nextId.Add(1)
+	chunkSize := a.maxMsgSize() - fragmentHeaderSize
+
+	for index, written := uint32(0), 0; written < len(msg.Data); index, written = index+1, written+chunkSize {
+		end := min(written+chunkSize, len(msg.Data))
+
+		fragment := &Message{MsgType: msg.MsgType, flags: flagFragment}
+		if end == len(msg.Data) {
+			fragment.flags |= flagFinal | msg.flags
+			fragment.id = msg.id
+		}
+		fragment.Data = make([]byte, fragmentHeaderSize, fragmentHeaderSize+end-written)
+		binary.BigEndian.PutUint32(fragment.Data[0:4], setId)
+		binary.BigEndian.PutUint32(fragment.Data[4:8], index)
+		fragment.Data = append(fragment.Data, msg.Data[written:end]...)
+
+		//the remote side discards the fragments already sent when the connection is lost
+		if a.getStatus() != Connected {
+			a.logger.Errorf("%s.Write err: %s", a, ErrConnectionLost)
+			return ErrConnectionLost
+		}
+
+		select {
+		case a.toWrite <- fragment:
+		case <-a.failed:
+			return ErrConnectionLost
+		case <-a.closed:
+			return ErrConnectionLost
+		case <-ctx.Done():
+			if index > 0 {
+				//the abort waits for the writer, which may be blocked longer than the caller accepts
+				go a.abortFragments(msg.MsgType, setId)
+			}
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+// abortFragments - tells the remote side to drop the fragments of the set already sent, which
+// would otherwise be buffered until the connection is lost
+func (a *Actor) abortFragments(msgType int, setId uint32) {
+
+	if a.getStatus() != Connected {
+		//the remote side discards the fragments when the connection is lost
+		return
+	}
+
+	abort := &Message{MsgType: msgType, flags: flagFragment | flagFinal, Data: make([]byte, fragmentHeaderSize)}
+	binary.BigEndian.PutUint32(abort.Data[0:4], setId)
+	binary.BigEndian.PutUint32(abort.Data[4:8], fragmentAbortIndex)
+
+	select {
+	case a.toWrite <- abort:
+	case <-a.failed:
+	case <-a.closed:
+	}
+}
+
+// reassemble - buffers the fragment, returns the reassembled message once the final fragment is read
+func (ft *fragmentTable) reassemble(a *Actor, msg *Message) *Message {
+
+	if len(msg.Data) < fragmentHeaderSize {
+		a.logger.Errorf("%s.read - discarding a fragment without a header", a)
+		return nil
+	}
+
+	setId := binary.BigEndian.Uint32(msg.Data[0:4])
+	index := binary.BigEndian.Uint32(msg.Data[4:8])
+
+	ft.mutex.Lock()
+	defer ft.mutex.Unlock()
+
+	if index == fragmentAbortIndex {
+		a.logger.Debugf("%s.read - the write of fragmented message of type %d was abandoned", a, msg.MsgType)
+		delete(ft.sets, setId)
+		return nil
+	}
+
+	set, ok := ft.sets[setId]
+	if !ok {
+		set = &fragmentSet{}
+		ft.sets[setId] = set
+	}
+
+	//fragments sent before the connection was lost are missing, the message can't be reassembled
+	if !set.discarded && index != set.next {
+		a.logger.Errorf("%s.read - discarding fragmented message of type %d missing fragment %d", a, msg.MsgType, set.next)
+		set.discarded = true
+		set.data = nil
+	}
+	set.next = index + 1
+
+	final := msg.flags&flagFinal != 0
+	if final {
+		delete(ft.sets, setId)
+	}
+	if set.discarded {
+		return nil
+	}
+
+	set.data = append(set.data, msg.Data[fragmentHeaderSize:]...)
+	if len(set.data) > a.maxFragmentedMsgSize() {
+		a.logger.Errorf("%s.read - discarding fragmented message of type %d exceeding the maximum fragmented message size", a, msg.MsgType)
+		set.discarded = true
+		set.data = nil
+		return nil
+	}
+
+	if !final {
+		return nil
+	}
+
+	return &Message{
+		MsgType: msg.MsgType,
+		Data:    set.data,
+		id:      msg.id,
+		flags:   msg.flags &^ (flagFragment | flagFinal),
+	}
+}
+
+// reset - discards the partially received messages when the connection is lost
+func (ft *fragmentTable) reset() {
+	ft.mutex.Lock()
+	ft.sets = make(map[uint32]*fragmentSet)
+	ft.mutex.Unlock()
+}
@@ -0,0 +1,193 @@
+package gipc
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"sync"
+	"testing"
+)
+
+// startFragmentPair - starts a server and a client with the fragmented message ceilings of each side
+func startFragmentPair(t *testing.T, name string, serverMax int, clientMax int) (*Server, *Client) {
+
+	scon := NewServerConfig(name)
+	scon.MaxMsgSize = 1024
+	scon.MaxFragmentedMsgSize = serverMax
+
+	ccon := NewClientConfig(name)
+	ccon.MaxFragmentedMsgSize = clientMax
+
+	return startConfiguredPair(t, scon, ccon)
+}
+
+func TestFragmentReassembly(t *testing.T) {
+
+	Sleep()
+
+	sc, cc := startFragmentPair(t, "test_fragment", 10000, 10000)
+	defer sc.Close()
+	defer cc.Close()
+
+	first := bytes.Repeat([]byte("a"), 5000)
+	second := bytes.Repeat([]byte("b"), 3000)
+
+	//concurrent fragmented writes interleave with each other and with small messages
+	var wg sync.WaitGroup
+	for i, data := range [][]byte{first, second, []byte("small")} {
+		wg.Add(1)
+		go func(msgType int, data []byte) {
+			defer wg.Done()
+			if err := cc.Write(msgType, data); err != nil {
+				t.Error(err)
+			}
+		}(i+1, data)
+	}
+	wg.Wait()
+
+	want := map[int][]byte{1: first, 2: second, 3: []byte("small")}
+	for len(want) > 0 {
+		m, err := sc.Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.MsgType < 0 {
+			continue
+		}
+		if !bytes.Equal(m.Data, want[m.MsgType]) {
+			t.Errorf("message type %d: Got %d bytes, Wanted %d bytes", m.MsgType, len(m.Data), len(want[m.MsgType]))
+		}
+		delete(want, m.MsgType)
+	}
+
+	if err := cc.Write(4, make([]byte, 10001)); err == nil || err.Error() != "message exceeds maximum message length" {
+		t.Errorf("expected the maximum message length error but got: %v", err)
+	}
+
+	//requests and replies are fragmented too
+	go func() {
+		for {
+			m, err := sc.Read()
+			if err != nil {
+				return
+			}
+			if m.IsRequest() {
+				sc.Reply(m, bytes.ToUpper(m.Data))
+			}
+		}
+	}()
+	go func() {
+		for {
+			if _, err := cc.Read(); err != nil {
+				return
+			}
+		}
+	}()
+
+	reply, err := cc.Request(context.Background(), 5, first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(reply.Data, bytes.ToUpper(first)) {
+		t.Errorf("Got %d bytes, Wanted %d bytes", len(reply.Data), len(first))
+	}
+}
+
+func TestFragmentReceiverCeiling(t *testing.T) {
+
+	Sleep()
+
+	sc, cc := startFragmentPair(t, "test_fragment_ceiling", 4000, 10000)
+	defer sc.Close()
+	defer cc.Close()
+
+	//the server discards the message exceeding its ceiling and reads the next one
+	if err := cc.Write(1, make([]byte, 6000)); err != nil {
+		t.Fatal(err)
+	}
+	if err := cc.Write(2, make([]byte, 3000)); err != nil {
+		t.Fatal(err)
+	}
+
+	for {
+		m, err := sc.Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.MsgType < 0 {
+			continue
+		}
+		if m.MsgType != 2 || len(m.Data) != 3000 {
+			t.Errorf("expected message type 2 of 3000 bytes but got type %d of %d bytes", m.MsgType, len(m.Data))
+		}
+		break
+	}
+
+	//the writer applies its own ceiling
+	if err := sc.Write(3, make([]byte, 2000)); err != nil {
+		t.Error(err)
+	}
+	if err := sc.Write(3, make([]byte, 5000)); err == nil {
+		t.Error("messages exceeding the ceiling of the writer should be rejected")
+	}
+}
+
+func TestFragmentAbort(t *testing.T) {
+
+	Sleep()
+
+	//the writer of a standalone client hands the fragments to the test
+	cc, err := NewClient("test_fragment_abort", &ClientConfig{MaxFragmentedMsgSize: 10000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cc.maxMsgSize = 1024
+	cc.setStatus(Connected)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	written := make(chan error, 1)
+	go func() {
+		written <- cc.writeFragments(ctx, &Message{MsgType: 1, Data: make([]byte, 5000)})
+	}()
+
+	first := <-cc.toWrite
+	cancel()
+	if err = <-written; !errors.Is(err, context.Canceled) {
+		t.Fatalf("Got %v, Wanted %v", err, context.Canceled)
+	}
+
+	//the fragments already sent are followed by the abort of their set
+	abort := <-cc.toWrite
+	if abort.flags&flagFinal == 0 || !bytes.Equal(abort.Data[0:4], first.Data[0:4]) || binary.BigEndian.Uint32(abort.Data[4:8]) != fragmentAbortIndex {
+		t.Fatalf("expected the abort of the fragment set but got: %+v", abort)
+	}
+
+	sc, pc := startFragmentPair(t, "test_fragment_abort", 10000, 10000)
+	defer sc.Close()
+	defer pc.Close()
+
+	//the receiver drops the set of the abandoned write
+	pc.toWrite <- first
+	pc.toWrite <- abort
+	if err = pc.Write(2, []byte("next")); err != nil {
+		t.Fatal(err)
+	}
+
+	for {
+		m, err := sc.Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.MsgType == 2 {
+			break
+		}
+	}
+
+	sc.fragments.mutex.Lock()
+	sets := len(sc.fragments.sets)
+	sc.fragments.mutex.Unlock()
+	if sets != 0 {
+		t.Errorf("Got %d fragment sets, Wanted 0", sets)
+	}
+}
@@ -0,0 +1,45 @@
+package gipc
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// every message is prefixed with a header before being encrypted:
+// bytes 0-3 = message type, byte 4 = flags, bytes 5-8 = correlation id
+const frameHeaderSize = 9
+
+const (
+	flagRequest  byte = 1 << iota // the sender is waiting for a reply carrying the same id
+	flagReply                     // the message is a reply to the request with the same id
+	flagError                     // the reply data is the error message returned by the remote handler
+	flagClose                     // the sender has closed the conn of the message type, with 4 bytes of data it grants more window instead
+	flagStream                    // a logical stream frame, the message type is the operation and the id the stream id
+	flagFragment                  // a fragment of a larger message, the data is prefixed with the fragment header
+	flagFinal                     // the last fragment, its header carries the id and flags of the reassembled message
+	flagFDs                       // file descriptors are attached to the message, the id is their number
+)
+
+func encodeFrame(m *Message) []byte {
+
+	b := make([]byte, frameHeaderSize, frameHeaderSize+len(m.Data))
+	binary.BigEndian.PutUint32(b[0:4], uint32(m.MsgType))
+	b[4] = m.flags
+	binary.BigEndian.PutUint32(b[5:9], m.id)
+
+	return append(b, m.Data...)
+}
+
+func decodeFrame(b []byte) (*Message, error) {
+
+	if len(b) < frameHeaderSize {
+		return nil, errors.New("message is shorter than the frame header")
+	}
+
+	return &Message{
+		MsgType: bytesToInt(b[0:4]),
+		flags:   b[4],
+		id:      binary.BigEndian.Uint32(b[5:9]),
+		Data:    b[frameHeaderSize:],
+	}, nil
+}
@@ -471,3 +471,58 @@ func TestReconnectServer2Multi(t *testing.T) {
 		}
 	}
 }
+
+func TestReconnectPoolHandshake(t *testing.T) {
+
+	for _, killLeader := range []bool{true, false} {
+
+		Sleep()
+
+		//the manager of the pool accepts the connection but never replies with a client id
+		name := "test_pool_handshake"
+		ms, err := StartServer(NewServerConfig(name + poolManagerSuffix))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		go func() {
+			for {
+				msg, err := ms.Read()
+				if err != nil {
+					return
+				}
+				if isClientIdRequest(msg) && killLeader {
+					ms.Close()
+					return
+				}
+			}
+		}()
+
+		Sleep()
+
+		ccon := NewClientConfig(name)
+		ccon.MultiClient = true
+		ccon.Timeout = 500 * time.Millisecond
+		ccon.RetryTimer = 100 * time.Millisecond
+
+		done := make(chan error, 1)
+		go func() {
+			cc, err := StartClient(ccon)
+			if err == nil {
+				cc.Close()
+			}
+			done <- err
+		}()
+
+		select {
+		case err = <-done:
+			if err == nil {
+				t.Errorf("killLeader %t: the client should not start without a client id", killLeader)
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatalf("killLeader %t: StartClient should have given up waiting for the client id", killLeader)
+		}
+
+		ms.Close()
+	}
+}
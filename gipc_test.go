@@ -1,9 +1,15 @@
 package gipc
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"net"
 	"os"
+	"runtime"
 	"strconv"
 	"strings"
 	"testing"
@@ -1138,3 +1144,205 @@ func TestBaseServerWrongEncryption2(t *testing.T) {
 		}
 	}
 }
+
+func TestBaseStartClientContext(t *testing.T) {
+
+	Sleep()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*200)
+	defer cancel()
+
+	//no server is listening so the dial loop should be abandoned when the context expires
+	cc, err := StartClientContext(ctx, NewClientConfig("test_start_context"))
+	defer cc.Close()
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded but got: %s", err)
+	}
+}
+
+func TestBaseStartClientContextHandshake(t *testing.T) {
+
+	Sleep()
+
+	//the listener accepts the connection but never starts the handshake
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			accepted <- conn
+		}
+	}()
+
+	ccon := NewClientConfig("test_start_context_handshake")
+	ccon.Transport = &NetworkTransport{Port: listener.Addr().(*net.TCPAddr).Port}
+	ccon.Timeout = -1
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*200)
+	defer cancel()
+
+	cc, err := StartClientContext(ctx, ccon)
+	defer cc.Close()
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded but got: %s", err)
+	}
+
+	//the connection of the abandoned handshake is closed
+	conn := <-accepted
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err = conn.Read(make([]byte, 1)); !errors.Is(err, io.EOF) {
+		t.Errorf("expected io.EOF but got: %v", err)
+	}
+}
+
+func TestBaseReadWriteContext(t *testing.T) {
+
+	Sleep()
+
+	sc, err := StartServer(NewServerConfig("test_rw_context"))
+	if err != nil {
+		t.Error(err)
+	}
+	defer sc.Close()
+
+	//the server is still listening so the write waits until the context is cancelled
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	err = sc.WriteContext(ctx, 5, []byte("too early"))
+	cancel()
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded but got: %s", err)
+	}
+
+	Sleep()
+
+	cc, err2 := StartClient(NewClientConfig("test_rw_context"))
+	if err2 != nil {
+		t.Error(err2)
+	}
+	defer cc.Close()
+
+	for {
+		m, err := cc.ReadContext(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.Status == "Connected" {
+			break
+		}
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Millisecond*50)
+	_, err = cc.ReadContext(ctx)
+	cancel()
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded but got: %s", err)
+	}
+
+	err = sc.WriteContext(context.Background(), 5, []byte("in time"))
+	if err != nil {
+		t.Error(err)
+	}
+
+	m, err := cc.ReadContext(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(m.Data) != "in time" {
+		t.Errorf("Got %q, Wanted %q", m.Data, "in time")
+	}
+}
+
+func TestBaseServerSecondClientClosed(t *testing.T) {
+
+	Sleep()
+
+	sc, err := StartServer(NewServerConfig("test_second_client"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sc.Close()
+
+	Sleep()
+
+	cc, err := StartClient(NewClientConfig("test_second_client"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+
+	waitForConnected(t, &sc.Actor)
+
+	//the server only serves one client, the second one is closed instead of waiting for its timeout
+	ccon := NewClientConfig("test_second_client")
+	ccon.Timeout = 5 * time.Second
+	start := time.Now()
+	cc2, err := StartClient(ccon)
+	if err == nil {
+		t.Error("the second client should not be served")
+	}
+	if time.Since(start) >= ccon.Timeout {
+		t.Error("the connection of the second client should be closed by the server")
+	}
+	cc2.Close()
+
+	//the first client is still served
+	if err = cc.Write(5, []byte("first")); err != nil {
+		t.Fatal(err)
+	}
+	m, err := sc.Read()
+	if err != nil || string(m.Data) != "first" {
+		t.Errorf("Got %+v %v, Wanted %q", m, err, "first")
+	}
+}
+
+// countWriters - the number of running writer goroutines of every actor
+func countWriters() int {
+	buff := make([]byte, 1<<20)
+	buff = buff[:runtime.Stack(buff, true)]
+	return bytes.Count(buff, []byte("gipc.(*Actor).write("))
+}
+
+func TestBaseServerWriterPerConnection(t *testing.T) {
+
+	Sleep()
+
+	writers := countWriters()
+
+	sc, err := StartServer(NewServerConfig("test_writer_per_conn"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sc.Close()
+
+	Sleep()
+
+	//the writer of each connection stops with it instead of competing with the writers of the next ones
+	for i := 0; i < 3; i++ {
+		cc, err := StartClient(NewClientConfig("test_writer_per_conn"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		waitForConnected(t, &sc.Actor)
+
+		cc.Close()
+		for sc.getStatus() == Connected {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for countWriters() > writers && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := countWriters(); n > writers {
+		t.Errorf("Got %d writers, Wanted at most %d", n, writers)
+	}
+}
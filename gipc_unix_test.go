@@ -3,9 +3,14 @@
 package gipc
 
 import (
+	"errors"
 	"fmt"
+	"net"
 	"os"
+	"path/filepath"
+	"syscall"
 	"testing"
+	"time"
 )
 
 func TestUnixUnmask(t *testing.T) {
@@ -34,6 +39,180 @@ func TestUnixUnmask(t *testing.T) {
 	}
 }
 
+func TestUnixSocketMode(t *testing.T) {
+
+	Sleep()
+
+	umask := syscall.Umask(0o022)
+	defer syscall.Umask(umask)
+
+	gid := socketTestGid(t)
+
+	scon := NewServerConfig("test_socket_mode")
+	scon.SocketMode = 0660
+	scon.SocketGID = &gid
+	sc, err := StartServer(scon)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sc.Close()
+
+	info, err := os.Stat(sc.GetListener().Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprintf("%04o", info.Mode().Perm()); got != "0660" {
+		t.Errorf("Got %q, Wanted %q", got, "0660")
+	}
+	if got := int(info.Sys().(*syscall.Stat_t).Gid); got != gid {
+		t.Errorf("Got gid %d, Wanted %d", got, gid)
+	}
+	//the owner is left unchanged
+	if uid := int(info.Sys().(*syscall.Stat_t).Uid); uid != os.Geteuid() {
+		t.Errorf("Got uid %d, Wanted %d", uid, os.Geteuid())
+	}
+
+	//the umask of the process is left untouched
+	if got := syscall.Umask(0o022); got != 0o022 {
+		t.Errorf("Got umask %04o, Wanted 0022", got)
+	}
+
+	Sleep()
+
+	cc, err := StartClient(NewClientConfig("test_socket_mode"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+
+	waitForConnected(t, &cc.Actor)
+}
+
+// socketTestGid - a group other than the primary group of the process, so the socket file is
+// actually changed by chown
+func socketTestGid(t *testing.T) int {
+
+	groups, _ := os.Getgroups()
+	for _, g := range groups {
+		if g != 0 && g != os.Getgid() {
+			return g
+		}
+	}
+
+	//root may change the group to any gid, i.e. nogroup
+	if os.Geteuid() == 0 {
+		return 65534
+	}
+
+	t.Skip("the process has no supplementary group to change the socket group to")
+	return 0
+}
+
+func TestUnixAddressInUse(t *testing.T) {
+
+	Sleep()
+
+	sc, err := StartServer(NewServerConfig("test_in_use"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sc.Close()
+
+	sc2, err := StartServer(NewServerConfig("test_in_use"))
+	if !errors.Is(err, ErrAddressInUse) {
+		t.Fatalf("Got %v, Wanted %v", err, ErrAddressInUse)
+	}
+	sc2.Close()
+
+	//the running server keeps its socket
+	cc, err := StartClient(NewClientConfig("test_in_use"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+
+	waitForConnected(t, &sc.Actor)
+	waitForConnected(t, &cc.Actor)
+
+	//a server which doesn't hold the lock is detected by dialing it
+	listener, err := net.Listen("unix", getSocketName(0, "test_in_use_unlocked"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	if _, err = StartServer(NewServerConfig("test_in_use_unlocked")); !errors.Is(err, ErrAddressInUse) {
+		t.Errorf("Got %v, Wanted %v", err, ErrAddressInUse)
+	}
+}
+
+func TestUnixServerPoolAddressInUse(t *testing.T) {
+
+	Sleep()
+
+	//the socket of the first client server is taken, the socket of the manager is not
+	sc, err := StartServer(NewServerConfig("test_pool_in_use1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scon := NewServerConfig("test_pool_in_use")
+	scon.MultiClient = true
+	if _, err = StartServer(scon); !errors.Is(err, ErrAddressInUse) {
+		t.Fatalf("Got %v, Wanted %v", err, ErrAddressInUse)
+	}
+
+	//the manager of the failed pool released its socket
+	sc.Close()
+
+	scon = NewServerConfig("test_pool_in_use")
+	scon.MultiClient = true
+	pool, err := StartServer(scon)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool.Close()
+}
+
+func TestUnixStaleSocket(t *testing.T) {
+
+	Sleep()
+
+	socketName := getSocketName(0, "test_stale")
+	listener, err := net.ListenUnix("unix", &net.UnixAddr{Name: socketName, Net: "unix"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	//leaves the socket file behind as a crashed server would
+	listener.SetUnlinkOnClose(false)
+	listener.Close()
+
+	sc, err := StartServer(NewServerConfig("test_stale"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cc, err := StartClient(NewClientConfig("test_stale"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+
+	waitForConnected(t, &cc.Actor)
+
+	//the lock is released once the server is closed
+	sc.Close()
+	if _, err = os.Stat(socketName + ".lock"); !os.IsNotExist(err) {
+		t.Errorf("the lock file should be removed but got: %v", err)
+	}
+
+	sc, err = StartServer(NewServerConfig("test_stale"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sc.Close()
+}
+
 // fails in network mode to due to reconnecting causing a hang
 func TestUnixServerClose(t *testing.T) {
 
@@ -82,3 +261,81 @@ func TestUnixServerClose(t *testing.T) {
 
 	<-holdIt
 }
+
+func TestUnixDialBacklogFull(t *testing.T) {
+
+	Sleep()
+
+	path := filepath.Join(t.TempDir(), "backlog.sock")
+
+	//a listener with a backlog of a single connection, which isn't accepted yet
+	fd, err := syscall.Socket(syscall.AF_UNIX, syscall.SOCK_STREAM, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = syscall.Bind(fd, &syscall.SockaddrUnix{Name: path}); err != nil {
+		t.Fatal(err)
+	}
+	if err = syscall.Listen(fd, 0); err != nil {
+		t.Fatal(err)
+	}
+	f := os.NewFile(uintptr(fd), path)
+	listener, err := net.FileListener(f)
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var pending []net.Conn
+	for len(pending) < 10 {
+		conn, err := net.Dial("unix", path)
+		if err != nil {
+			break
+		}
+		pending = append(pending, conn)
+	}
+
+	ccon := NewClientConfig("test_backlog")
+	ccon.SocketPath = path
+	ccon.RetryTimer = 50 * time.Millisecond
+	ccon.Timeout = 5 * time.Second
+
+	started := make(chan error, 1)
+	var cc *Client
+	go func() {
+		var err error
+		cc, err = StartClient(ccon)
+		started <- err
+	}()
+
+	//the client keeps dialing while the backlog is full
+	select {
+	case err = <-started:
+		t.Fatalf("the client should retry while the backlog is full, got: %v", err)
+	case <-time.After(300 * time.Millisecond):
+	}
+
+	for range pending {
+		conn, err := listener.Accept()
+		if err != nil {
+			t.Fatal(err)
+		}
+		conn.Close()
+	}
+	for _, conn := range pending {
+		conn.Close()
+	}
+
+	scon := NewServerConfig("test_backlog")
+	scon.Transport = &ListenerTransport{Listener: listener}
+	sc, err := StartServer(scon)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sc.Close()
+
+	if err = <-started; err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+}
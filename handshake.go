@@ -4,8 +4,20 @@ import (
 	"bytes"
 	"encoding/binary"
 	"errors"
+	"io"
+	"net"
 )
 
+// handshakeRejected - sent instead of the encryption flag when the Authorize hook rejects the client
+const handshakeRejected = 2
+
+// ErrRejected - returned by the client when the server rejected its credentials
+var ErrRejected = errors.New("gipc: the server rejected the connection")
+
+// errHandshakeAborted - the client hung up before replying to the handshake (i.e. a server probing
+// whether the socket is live), the server keeps listening
+var errHandshakeAborted = errors.New("client closed the connection during the handshake")
+
 // 1st message sent from the server
 // byte 0 = protocol VERSION no.
 func (sc *Server) handshake() error {
@@ -30,6 +42,12 @@ func (sc *Server) handshake() error {
 	return nil
 }
 
+// reject - sends the handshake of a rejected client before closing its connection
+func (sc *Server) reject(conn net.Conn) {
+	conn.Write([]byte{byte(VERSION), handshakeRejected})
+	conn.Close()
+}
+
 func (sc *Server) one() error {
 
 	buff := make([]byte, 2)
@@ -42,14 +60,17 @@ func (sc *Server) one() error {
 		buff[1] = byte(0)
 	}
 
+	//the client can only be gone before the first write
 	_, err := sc.getConn().Write(buff)
 	if err != nil {
-		return errors.New("unable to send handshake ")
+		return errHandshakeAborted
 	}
 
 	recv := make([]byte, 1)
 	_, err = sc.getConn().Read(recv)
-	if err != nil {
+	if err == io.EOF {
+		return errHandshakeAborted
+	} else if err != nil {
 		return errors.New("failed to received handshake reply")
 	}
 
@@ -136,6 +157,10 @@ func (cc *Client) one() error {
 		return errors.New("server has sent a different VERSION number")
 	}
 
+	if recv[1] == handshakeRejected {
+		return ErrRejected
+	}
+
 	if recv[1] != 1 && cc.shouldUseEncryption() {
 		cc.handshakeSendReply(2)
 		return errors.New("server tried to connect without encryption")
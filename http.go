@@ -0,0 +1,358 @@
+package gipc
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// serverListener - a net.Listener accepting a conn for every connection made to a Server,
+// or to each client server in MultiClient mode
+type serverListener struct {
+	server     *Server
+	msgType    int
+	pending    []net.Conn    // the conns waiting for Accept
+	accepted   chan struct{} // signalled when a conn is added to pending
+	done       chan struct{}
+	once       sync.Once
+	mutex      sync.Mutex
+	routes     []*listenerRoute
+	unregister func() // stops adding the servers added to the pool later
+}
+
+// listenerRoute - the route of a single server, a new conn is accepted when the first
+// message of a new client conn arrives
+type listenerRoute struct {
+	listener *serverListener
+	server   *Server
+	mutex    sync.Mutex
+	current  *actorConn
+}
+
+// roundTripper - an http.RoundTripper sending each request over a conn of a Client
+type roundTripper struct {
+	client  *Client
+	msgType int
+	sem     chan struct{} // held by a request until its response body is released
+	conn    *actorConn
+	reader  *bufio.Reader
+}
+
+// NewListener - returns a net.Listener backed by s so a plain http.Server can serve over gipc.
+// A conn is accepted for each client connection (each pool client in MultiClient mode) once it
+// sends its first message of msgType. The listener reads the messages of s, so s must not be
+// read elsewhere. Closing the listener does not close s.
+func NewListener(s *Server, msgType int) (net.Listener, error) {
+
+	l := &serverListener{
+		server:   s,
+		msgType:  msgType,
+		accepted: make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+
+	if s.Connections == nil {
+		if err := l.addServer(s); err != nil {
+			return nil, err
+		}
+		return l, nil
+	}
+
+	var err error
+	l.unregister = s.Connections.forEachServer(func(ps *Server) {
+		if addErr := l.addServer(ps); addErr != nil {
+			err = addErr
+			s.logger.Errorf("%s.Listener err: %s", ps, addErr)
+		}
+	})
+
+	return l, err
+}
+
+func (l *serverListener) addServer(s *Server) error {
+
+	lr := &listenerRoute{listener: l, server: s}
+	if !s.routes.add(l.msgType, lr) {
+		return errors.New("the message type is already in use by another conn")
+	}
+
+	l.mutex.Lock()
+	l.routes = append(l.routes, lr)
+	l.mutex.Unlock()
+
+	go l.drain(s)
+
+	return nil
+}
+
+// drain - discards the status messages and other message types received by the server
+func (l *serverListener) drain(s *Server) {
+	for {
+		select {
+		case <-l.done:
+			return
+		default:
+		}
+
+		msg, err := s.readUntilClosed()
+		if err == errReceivedChannelClosed {
+			return
+		} else if err != nil {
+			s.logger.Debugf("%s.Listener err: %s", s, err)
+			if s.getStatus() == Closed {
+				return
+			}
+		} else if msg.MsgType != -1 {
+			s.logger.Debugf("%s.Listener discarding message type %d", s, msg.MsgType)
+		}
+	}
+}
+
+// deliver - queues a new conn for Accept when the message belongs to a new client conn
+func (lr *listenerRoute) deliver(msg *Message) {
+
+	lr.mutex.Lock()
+	current := lr.current
+	if current == nil || !current.alive() || current.gen != msg.id {
+		if msg.flags&flagClose != 0 {
+			//the remote side closed a conn which is no longer accepted
+			lr.mutex.Unlock()
+			return
+		}
+		if current != nil {
+			//the client replaced its conn, the previous one won't receive anything else
+			current.deliver(&Message{flags: flagClose, id: current.gen})
+		}
+		current = newActorConn(&lr.server.Actor, lr.listener.msgType, msg.id)
+		lr.current = current
+		lr.mutex.Unlock()
+
+		if !lr.listener.queue(current) {
+			return
+		}
+	} else {
+		lr.mutex.Unlock()
+	}
+
+	current.deliver(msg)
+}
+
+func (lr *listenerRoute) connectionLost() {
+	lr.mutex.Lock()
+	current := lr.current
+	lr.current = nil
+	lr.mutex.Unlock()
+
+	if current != nil {
+		current.connectionLost()
+	}
+}
+
+// queue - adds the conn to those waiting for Accept, returns false once the listener is closed
+func (l *serverListener) queue(conn net.Conn) bool {
+
+	l.mutex.Lock()
+	select {
+	case <-l.done:
+		l.mutex.Unlock()
+		return false
+	default:
+	}
+	l.pending = append(l.pending, conn)
+	l.mutex.Unlock()
+
+	signal(l.accepted)
+	return true
+}
+
+func (l *serverListener) Accept() (net.Conn, error) {
+
+	for {
+		l.mutex.Lock()
+		if len(l.pending) > 0 {
+			conn := l.pending[0]
+			l.pending = l.pending[1:]
+			l.mutex.Unlock()
+			return conn, nil
+		}
+		l.mutex.Unlock()
+
+		select {
+		case <-l.accepted:
+		case <-l.done:
+			return nil, net.ErrClosed
+		}
+	}
+}
+
+func (l *serverListener) Close() error {
+
+	l.once.Do(func() {
+		l.mutex.Lock()
+		close(l.done)
+		routes, pending := l.routes, l.pending
+		l.pending = nil
+		l.mutex.Unlock()
+
+		if l.unregister != nil {
+			l.unregister()
+		}
+		for _, lr := range routes {
+			lr.server.routes.remove(l.msgType, lr)
+		}
+		//the conns which were never accepted notify their remote side
+		for _, conn := range pending {
+			conn.Close()
+		}
+	})
+
+	return nil
+}
+
+func (l *serverListener) Addr() net.Addr {
+	return actorAddr{name: l.server.name(), msgType: l.msgType}
+}
+
+// NewRoundTripper - returns an http.RoundTripper sending requests over a conn of c carrying
+// messages of msgType, so a plain http.Client can call an http.Server served with NewListener.
+// Requests are sent one at a time: each response body is streamed from the conn, so the next
+// request waits until the body of the previous response is read to EOF or closed, or until its
+// context is done.
+func NewRoundTripper(c *Client, msgType int) http.RoundTripper {
+	return &roundTripper{client: c, msgType: msgType, sem: make(chan struct{}, 1)}
+}
+
+func (rt *roundTripper) resetConn() {
+	if rt.conn != nil {
+		rt.conn.Close()
+	}
+	rt.conn = nil
+	rt.reader = nil
+}
+
+func (rt *roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+
+	ctx := req.Context()
+
+	//a request queued behind the body of the previous response can still be cancelled
+	select {
+	case rt.sem <- struct{}{}:
+	case <-ctx.Done():
+		closeRequestBody(req)
+		return nil, ctx.Err()
+	}
+
+	if rt.conn != nil && !rt.conn.alive() {
+		//the server closed the conn or the connection was lost while idle
+		rt.resetConn()
+	}
+
+	if rt.conn == nil {
+		conn, err := rt.client.asConn(rt.msgType, nextConnGen())
+		if err != nil {
+			<-rt.sem
+			closeRequestBody(req)
+			return nil, err
+		}
+		rt.conn = conn
+		rt.reader = bufio.NewReader(conn)
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		rt.conn.SetDeadline(deadline)
+	}
+	//a cancelled request wakes up the blocked reads and writes of the conn
+	conn := rt.conn
+	stop := context.AfterFunc(ctx, func() {
+		conn.SetDeadline(time.Unix(1, 0))
+	})
+
+	body := &responseBody{rt: rt, ctx: ctx, stop: stop}
+
+	//Write closes the body of the request
+	err := req.Write(rt.conn)
+	if err != nil {
+		return nil, body.fail(err)
+	}
+
+	resp, err := http.ReadResponse(rt.reader, req)
+	if err != nil {
+		return nil, body.fail(err)
+	}
+
+	if resp.Body == http.NoBody {
+		body.release(!resp.Close)
+		return resp, nil
+	}
+
+	body.ReadCloser = resp.Body
+	body.closeConn = resp.Close
+	resp.Body = body
+
+	return resp, nil
+}
+
+// responseBody - the body of a response streamed from the conn of the roundTripper, which is
+// released for the next request once the body is read to EOF or closed
+type responseBody struct {
+	io.ReadCloser
+	rt        *roundTripper
+	ctx       context.Context
+	stop      func() bool // stops cancelling the conn with the request
+	closeConn bool        // the server closes the conn after the response
+	once      sync.Once
+}
+
+func (b *responseBody) Read(p []byte) (int, error) {
+
+	n, err := b.ReadCloser.Read(p)
+	if err == io.EOF {
+		b.release(true)
+	} else if err != nil {
+		err = b.fail(err)
+	}
+
+	return n, err
+}
+
+func (b *responseBody) Close() error {
+	//the rest of an unread body would be read as the next response
+	b.release(false)
+	b.ReadCloser.Close()
+	return nil
+}
+
+// fail - releases the conn which can't be reused after an error, returns ctx.Err() when the
+// error was caused by the cancelled request
+func (b *responseBody) fail(err error) error {
+	b.release(false)
+	if b.ctx.Err() != nil {
+		return b.ctx.Err()
+	}
+	return err
+}
+
+// release - releases the roundTripper for the next request, the conn is only kept when the whole response was read
+func (b *responseBody) release(complete bool) {
+	b.once.Do(func() {
+		//the request was cancelled meanwhile when the deadline of the conn could not be stopped
+		if !b.stop() || !complete || b.closeConn {
+			b.rt.resetConn()
+		} else {
+			b.rt.conn.SetDeadline(time.Time{})
+		}
+		<-b.rt.sem
+	})
+}
+
+// closeRequestBody - a RoundTripper must close the body of the request, even on errors
+func closeRequestBody(req *http.Request) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+}
@@ -0,0 +1,365 @@
+package gipc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestHTTPServer() *http.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/echo", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Method", r.Method)
+		w.Write(body)
+	})
+	mux.HandleFunc("/close", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Connection", "close")
+		io.WriteString(w, "closed")
+	})
+	return &http.Server{Handler: mux}
+}
+
+// startHTTPPair - a server with a listener served by hs and a client connected to it
+func startHTTPPair(t *testing.T, name string, hs *http.Server) (*Server, *Client, *http.Client) {
+
+	sc, err := StartServer(NewServerConfig(name))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := NewListener(sc, HTTP_MSGTYPE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go hs.Serve(l)
+
+	Sleep()
+
+	cc, err := StartClient(NewClientConfig(name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	drainClient(cc)
+
+	return sc, cc, &http.Client{Transport: NewRoundTripper(cc, HTTP_MSGTYPE)}
+}
+
+func drainClient(cc *Client) {
+	go func() {
+		for {
+			if _, err := cc.Read(); err != nil {
+				return
+			}
+		}
+	}()
+}
+
+func httpGet(t *testing.T, hc *http.Client, url string) string {
+	resp, err := hc.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+func TestHTTPListener(t *testing.T) {
+
+	Sleep()
+
+	hs := newTestHTTPServer()
+	defer hs.Close()
+
+	sc, cc, hc := startHTTPPair(t, "test_http", hs)
+	defer sc.Close()
+	defer cc.Close()
+
+	large := strings.Repeat("b", MAX_MSG_SIZE*2)
+	for _, body := range []string{"hello", large, "again"} {
+		resp, err := hc.Post("http://gipc/echo", "text/plain", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK || resp.Header.Get("X-Method") != http.MethodPost {
+			t.Errorf("unexpected response: %d %v", resp.StatusCode, resp.Header)
+		}
+		if string(got) != body {
+			t.Errorf("Got %d bytes, Wanted %d bytes", len(got), len(body))
+		}
+	}
+
+	//the server closing the conn makes the next request use a new conn
+	if got := httpGet(t, hc, "http://gipc/close"); got != "closed" {
+		t.Errorf("Got %q, Wanted %q", got, "closed")
+	}
+	if got := httpGet(t, hc, "http://gipc/echo"); got != "" {
+		t.Errorf("Got %q, Wanted an empty body", got)
+	}
+
+	resp, err := hc.Get("http://gipc/missing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Got %d, Wanted %d", resp.StatusCode, http.StatusNotFound)
+	}
+
+	if _, err = NewListener(sc, HTTP_MSGTYPE); err == nil {
+		t.Error("a message type should only be used by one listener")
+	}
+}
+
+func TestHTTPListenerMultiClient(t *testing.T) {
+
+	Sleep()
+
+	scon := NewServerConfig("test_http_multi")
+	scon.MultiClient = true
+	sc, err := StartServer(scon)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sc.Close()
+
+	l, err := NewListener(sc, HTTP_MSGTYPE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hs := newTestHTTPServer()
+	go hs.Serve(l)
+	defer hs.Close()
+
+	Sleep()
+
+	for _, name := range []string{"first", "second", "third"} {
+		ccon := NewClientConfig("test_http_multi")
+		ccon.MultiClient = true
+		cc, err := StartClient(ccon)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer cc.Close()
+		drainClient(cc)
+
+		hc := &http.Client{Transport: NewRoundTripper(cc, HTTP_MSGTYPE)}
+		resp, err := hc.Post("http://gipc/echo", "text/plain", strings.NewReader(name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if string(got) != name {
+			t.Errorf("Got %q, Wanted %q", got, name)
+		}
+	}
+}
+
+func TestHTTPListenerNotAccepted(t *testing.T) {
+
+	Sleep()
+
+	sc, err := StartServer(NewServerConfig("test_http_not_accepted"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sc.Close()
+
+	//Accept is never called
+	l, err := NewListener(sc, HTTP_MSGTYPE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	sconn, err := sc.AsConn(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	Sleep()
+
+	cc, err := StartClient(NewClientConfig("test_http_not_accepted"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+	drainClient(cc)
+
+	hconn, err := cc.asConn(HTTP_MSGTYPE, nextConnGen())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = hconn.Write([]byte("GET / HTTP/1.1\r\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	cconn, err := cc.AsConn(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = cconn.Write([]byte("ping")); err != nil {
+		t.Fatal(err)
+	}
+
+	//the conn waiting for Accept doesn't hold up the messages of other types
+	sconn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buff := make([]byte, 4)
+	if _, err = io.ReadFull(sconn, buff); err != nil || string(buff) != "ping" {
+		t.Errorf("Got %q %v, Wanted %q", buff, err, "ping")
+	}
+}
+
+func TestHTTPRoundTripStreamAndCancel(t *testing.T) {
+
+	Sleep()
+
+	release := make(chan struct{})
+	defer close(release)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/stream", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "first")
+		w.(http.Flusher).Flush()
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	})
+	mux.HandleFunc("/echo", func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(w, r.Body)
+	})
+	hs := &http.Server{Handler: mux}
+	defer hs.Close()
+
+	sc, cc, hc := startHTTPPair(t, "test_http_stream", hs)
+	defer sc.Close()
+	defer cc.Close()
+
+	//the response is returned before its body is complete
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://gipc/stream", nil)
+	resp, err := hc.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buff := make([]byte, 5)
+	if _, err = io.ReadFull(resp.Body, buff); err != nil || string(buff) != "first" {
+		t.Errorf("Got %q %v, Wanted %q", buff, err, "first")
+	}
+
+	//cancelling the request without a deadline ends the read of the body
+	time.AfterFunc(50*time.Millisecond, cancel)
+	if _, err = resp.Body.Read(buff); !errors.Is(err, context.Canceled) {
+		t.Errorf("Got %v, Wanted %v", err, context.Canceled)
+	}
+	resp.Body.Close()
+
+	//the next request uses a new conn
+	if got := httpGet(t, hc, "http://gipc/echo"); got != "" {
+		t.Errorf("Got %q, Wanted an empty body", got)
+	}
+
+	//cancelling the request while waiting for the response
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	req, _ = http.NewRequestWithContext(ctx, http.MethodGet, "http://gipc/stream", nil)
+	time.AfterFunc(50*time.Millisecond, cancel)
+	resp, err = hc.Do(req)
+	if err == nil {
+		//the headers were read before the cancel
+		_, err = io.ReadAll(resp.Body)
+		resp.Body.Close()
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Got %v, Wanted %v", err, context.Canceled)
+	}
+}
+
+// trackedBody - a request body recording whether it was closed
+type trackedBody struct {
+	io.Reader
+	closed atomic.Bool
+}
+
+func (b *trackedBody) Close() error {
+	b.closed.Store(true)
+	return nil
+}
+
+func TestHTTPRoundTripQueuedCancel(t *testing.T) {
+
+	Sleep()
+
+	release := make(chan struct{})
+	defer close(release)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/stream", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "first")
+		w.(http.Flusher).Flush()
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	})
+	hs := &http.Server{Handler: mux}
+	defer hs.Close()
+
+	sc, cc, hc := startHTTPPair(t, "test_http_queued", hs)
+	defer sc.Close()
+	defer cc.Close()
+
+	//the body of the first response is never read, the next request waits for it
+	resp, err := hc.Get("http://gipc/stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	body := &trackedBody{Reader: strings.NewReader("queued")}
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, "http://gipc/stream", body)
+
+	start := time.Now()
+	if _, err = hc.Transport.RoundTrip(req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Got %v, Wanted %v", err, context.DeadlineExceeded)
+	}
+	if time.Since(start) > 2*time.Second {
+		t.Error("the queued request should end with its context")
+	}
+	if !body.closed.Load() {
+		t.Error("the body of the queued request should be closed")
+	}
+
+	//the body is closed when the conn can't be created either
+	if _, err = cc.AsConn(40); err != nil {
+		t.Fatal(err)
+	}
+	body = &trackedBody{Reader: strings.NewReader("no conn")}
+	req, _ = http.NewRequest(http.MethodPost, "http://gipc/stream", body)
+	if _, err = NewRoundTripper(cc, 40).RoundTrip(req); err == nil {
+		t.Error("the round trip should fail when the message type is used by another conn")
+	}
+	if !body.closed.Load() {
+		t.Error("the body of the failed request should be closed")
+	}
+}
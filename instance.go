@@ -0,0 +1,182 @@
+package gipc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"time"
+)
+
+// InstancePayload - forwarded by a later launch of the application to its primary instance
+type InstancePayload struct {
+	Args []string `json:"args"`
+	Env  []string `json:"env"`
+	Dir  string   `json:"dir"`
+}
+
+// InstanceFunc - called by the primary instance with the payload of each later launch
+type InstanceFunc func(payload *InstancePayload)
+
+// InstanceConfig - used to pass configuration overrides to StartSingleInstance()
+type InstanceConfig struct {
+	Name     string
+	Received InstanceFunc     // called by the primary instance with the payload of each later launch
+	Payload  *InstancePayload // forwarded by a later launch (default is the args, environment and working directory of the process)
+	Timeout  time.Duration    // the duration a later launch waits for the primary instance (default is 5 seconds)
+	Retry    time.Duration    // the duration a later launch waits before connecting again while the primary instance serves another launch (default is 50 milliseconds)
+	Server   *ServerConfig    // overrides the config of the primary instance, Name is always set and MultiClient is always false
+	Client   *ClientConfig    // overrides the config of later launches, must match Server
+}
+
+// Instance - the result of SingleInstance, Server is only set on the primary instance
+type Instance struct {
+	Primary bool // false when the payload was forwarded to the primary instance, the caller should exit
+	Server  *Server
+}
+
+// SingleInstance - starts the primary instance of the application named name, which receives the
+// payloads of later launches through received. When a live primary instance already owns the name
+// the args, environment and working directory of the process are forwarded to it instead and the
+// returned Instance is not Primary.
+func SingleInstance(name string, received InstanceFunc) (*Instance, error) {
+	return StartSingleInstance(&InstanceConfig{Name: name, Received: received})
+}
+
+// StartSingleInstance - SingleInstance with configuration overrides
+func StartSingleInstance(config *InstanceConfig) (*Instance, error) {
+
+	scon := &ServerConfig{Encryption: ENCRYPT_BY_DEFAULT}
+	if config.Server != nil {
+		sc := *config.Server
+		scon = &sc
+	}
+	scon.Name = config.Name
+	//a single server serves the later launches one at a time, a pool would keep a server for each of them
+	scon.MultiClient = false
+
+	s, err := StartServer(scon)
+	if err == nil {
+		go s.Serve(instanceMux(config.Received))
+		return &Instance{Primary: true, Server: s}, nil
+	} else if !errors.Is(err, ErrAddressInUse) {
+		return nil, err
+	}
+
+	payload := config.Payload
+	if payload == nil {
+		payload, err = currentInstancePayload()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err = forwardInstancePayload(config, payload); err != nil {
+		return nil, err
+	}
+
+	return &Instance{Primary: false}, nil
+}
+
+// Close - closes the server of the primary instance, releasing the name
+func (i *Instance) Close() {
+	if i.Server != nil {
+		i.Server.Close()
+	}
+}
+
+func currentInstancePayload() (*InstancePayload, error) {
+
+	dir, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+
+	return &InstancePayload{Args: os.Args, Env: os.Environ(), Dir: dir}, nil
+}
+
+// instanceMux - acknowledges each payload before passing it to received
+func instanceMux(received InstanceFunc) *ServeMux {
+
+	mux := NewServeMux()
+	mux.HandleFunc(INSTANCE_MSGTYPE, func(ctx context.Context, a *Actor, msg *Message) {
+
+		var payload InstancePayload
+		if err := json.Unmarshal(msg.Data, &payload); err != nil {
+			a.ReplyError(msg, err)
+			return
+		}
+
+		if err := a.Reply(msg, nil); err != nil {
+			a.logger.Errorf("%s.SingleInstance err: %s", a, err)
+		}
+
+		if received != nil {
+			received(&payload)
+		}
+	})
+
+	return mux
+}
+
+// forwardInstancePayload - waits until the primary instance has acknowledged the payload
+func forwardInstancePayload(config *InstanceConfig, payload *InstancePayload) error {
+
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+
+	ccon := &ClientConfig{Encryption: ENCRYPT_BY_DEFAULT}
+	if config.Client != nil {
+		cc := *config.Client
+		ccon = &cc
+	}
+	ccon.Name = config.Name
+	ccon.MultiClient = false
+	if ccon.Timeout <= 0 {
+		ccon.Timeout = timeout
+	}
+
+	retry := config.Retry
+	if retry <= 0 {
+		retry = 50 * time.Millisecond
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	for {
+		//the primary instance closes the connections of concurrent launches before the handshake
+		cc, err := StartClientContext(ctx, ccon)
+		if err == nil {
+			_, err = cc.Request(ctx, INSTANCE_MSGTYPE, data)
+			releaseClient(cc)
+			return err
+		}
+		releaseClient(cc)
+		if ctx.Err() != nil || errors.Is(err, ErrRejected) {
+			return err
+		}
+
+		select {
+		case <-time.After(retry):
+		case <-ctx.Done():
+			return err
+		}
+	}
+}
+
+// releaseClient - closes cc, which is never read. Close stops its writer, the status dispatches
+// still waiting for a Read (i.e. Closed) are released by failing the client.
+func releaseClient(cc *Client) {
+	if cc != nil {
+		cc.Close()
+		cc.fail()
+	}
+}
@@ -0,0 +1,145 @@
+package gipc
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestSingleInstance(t *testing.T) {
+
+	Sleep()
+
+	received := make(chan *InstancePayload, 2)
+	primary, err := SingleInstance("test_single_instance", func(payload *InstancePayload) {
+		received <- payload
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer primary.Close()
+
+	if !primary.Primary || primary.Server == nil {
+		t.Fatal("the first launch should be the primary instance")
+	}
+
+	Sleep()
+
+	//a later launch forwards the args, environment and working directory of its process
+	launch, err := SingleInstance("test_single_instance", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if launch.Primary {
+		t.Fatal("a later launch should not be the primary instance")
+	}
+
+	dir, _ := os.Getwd()
+	select {
+	case payload := <-received:
+		if !reflect.DeepEqual(payload.Args, os.Args) || payload.Dir != dir || len(payload.Env) != len(os.Environ()) {
+			t.Errorf("unexpected payload %+v", payload)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the primary instance did not receive the payload")
+	}
+
+	want := &InstancePayload{Args: []string{"app", "--open", "file.txt"}, Dir: "/home"}
+	launch, err = StartSingleInstance(&InstanceConfig{Name: "test_single_instance", Payload: want})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if launch.Primary {
+		t.Fatal("a later launch should not be the primary instance")
+	}
+
+	select {
+	case payload := <-received:
+		if !reflect.DeepEqual(payload, want) {
+			t.Errorf("Got %+v, Wanted %+v", payload, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the primary instance did not receive the payload")
+	}
+
+	//the name is released once the primary instance is closed
+	primary.Close()
+
+	Sleep()
+
+	next, err := SingleInstance("test_single_instance", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer next.Close()
+
+	if !next.Primary {
+		t.Error("the next launch should become the primary instance")
+	}
+}
+
+func TestSingleInstanceConcurrentLaunches(t *testing.T) {
+
+	Sleep()
+
+	received := make(chan *InstancePayload, 10)
+	primary, err := SingleInstance("test_single_instance_concurrent", func(payload *InstancePayload) {
+		received <- payload
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer primary.Close()
+
+	//the launches are served one at a time by a single server instead of a server each
+	if primary.Server.Connections != nil {
+		t.Fatal("the primary instance should not run a server pool")
+	}
+
+	Sleep()
+
+	goroutines := runtime.NumGoroutine()
+
+	launches := 5
+	errs := make(chan error, launches)
+	for i := 0; i < launches; i++ {
+		go func(i int) {
+			_, err := StartSingleInstance(&InstanceConfig{
+				Name:    "test_single_instance_concurrent",
+				Payload: &InstancePayload{Args: []string{fmt.Sprint(i)}},
+			})
+			errs <- err
+		}(i)
+	}
+
+	for i := 0; i < launches; i++ {
+		if err := <-errs; err != nil {
+			t.Error(err)
+		}
+	}
+
+	seen := map[string]bool{}
+	for i := 0; i < launches; i++ {
+		select {
+		case payload := <-received:
+			seen[payload.Args[0]] = true
+		case <-time.After(5 * time.Second):
+			t.Fatal("the primary instance did not receive every payload")
+		}
+	}
+	if len(seen) != launches {
+		t.Errorf("Got %d distinct payloads, Wanted %d", len(seen), launches)
+	}
+
+	//the retried launches don't leave goroutines behind
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > goroutines+launches && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > goroutines+launches {
+		t.Errorf("Got %d goroutines, Wanted at most %d", n, goroutines+launches)
+	}
+}
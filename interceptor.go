@@ -0,0 +1,149 @@
+package gipc
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// WriteFunc - writes a message to the connection of an Actor
+type WriteFunc func(ctx context.Context, a *Actor, msg *Message) error
+
+// Interceptor - middleware wrapping the dispatch of inbound messages to handlers and the
+// outbound messages written by an Actor (Write, Request and Reply). Either field may be nil.
+// Interceptors are applied in order, the first being the outermost.
+//
+// Inbound wraps the handlers of a ServeMux run by Serve or ServeContext. The messages returned by
+// Read, ReadContext and ReadTimed (and so those consumed by ServeRPC and codecs) and those delivered
+// to conns and NewListener pass through it before they are returned or delivered: an Inbound
+// interceptor which doesn't call next drops the message. Replies to requests and the frames of
+// a stream, once the message opening it was accepted, never pass through it.
+type Interceptor struct {
+	Inbound  func(next Handler) Handler
+	Outbound func(next WriteFunc) WriteFunc
+}
+
+func (a *Actor) getInterceptors() []Interceptor {
+	if a.config == nil {
+		return nil
+	} else if a.config.IsServer {
+		return a.config.ServerConfig.Interceptors
+	} else {
+		return a.config.ClientConfig.Interceptors
+	}
+}
+
+func (a *Actor) inboundChain(handler Handler) Handler {
+	interceptors := a.getInterceptors()
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		if interceptors[i].Inbound != nil {
+			handler = interceptors[i].Inbound(handler)
+		}
+	}
+	return handler
+}
+
+// intercept - passes msg through the Inbound interceptors, returns nil when one of them drops it
+// or panics
+func (a *Actor) intercept(ctx context.Context, msg *Message) (delivered *Message) {
+
+	defer func() {
+		if r := recover(); r != nil {
+			a.logger.Errorf("%s dropped message type %d, an Inbound interceptor panicked: %v", a, msg.MsgType, r)
+			delivered = nil
+		}
+	}()
+
+	a.inboundChain(HandlerFunc(func(_ context.Context, _ *Actor, m *Message) {
+		delivered = m
+	})).ServeIPC(ctx, a, msg)
+
+	if delivered == nil {
+		a.logger.Debugf("%s dropped message type %d, an Inbound interceptor didn't call next", a, msg.MsgType)
+	}
+	return delivered
+}
+
+func (a *Actor) outboundChain(write WriteFunc) WriteFunc {
+	interceptors := a.getInterceptors()
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		if interceptors[i].Outbound != nil {
+			write = interceptors[i].Outbound(write)
+		}
+	}
+	return write
+}
+
+// WithLogging - logs every inbound and outbound message, the actor logger is used when logger is nil
+func WithLogging(logger *logrus.Logger) Interceptor {
+
+	getLogger := func(a *Actor) *logrus.Logger {
+		if logger != nil {
+			return logger
+		}
+		return a.logger
+	}
+
+	return Interceptor{
+		Inbound: func(next Handler) Handler {
+			return HandlerFunc(func(ctx context.Context, a *Actor, msg *Message) {
+				start := time.Now()
+				next.ServeIPC(ctx, a, msg)
+				getLogger(a).Infof("%s received message type %d (%d bytes) handled in %s", a, msg.MsgType, len(msg.Data), time.Since(start))
+			})
+		},
+		Outbound: func(next WriteFunc) WriteFunc {
+			return func(ctx context.Context, a *Actor, msg *Message) error {
+				err := next(ctx, a, msg)
+				if err != nil {
+					getLogger(a).Errorf("%s failed to write message type %d (%d bytes): %s", a, msg.MsgType, len(msg.Data), err)
+				} else {
+					getLogger(a).Infof("%s wrote message type %d (%d bytes)", a, msg.MsgType, len(msg.Data))
+				}
+				return err
+			}
+		},
+	}
+}
+
+// WithRecover - recovers from panics in the inbound handlers, passing the error to onPanic when it isn't nil
+func WithRecover(onPanic func(a *Actor, msg *Message, err error)) Interceptor {
+	return Interceptor{
+		Inbound: func(next Handler) Handler {
+			return HandlerFunc(func(ctx context.Context, a *Actor, msg *Message) {
+				defer func() {
+					if r := recover(); r != nil {
+						err := fmt.Errorf("handler for message type %d panicked: %v", msg.MsgType, r)
+						a.logger.Errorf("%s.Serve err: %s", a, err)
+						if onPanic != nil {
+							onPanic(a, msg, err)
+						}
+					}
+				}()
+				next.ServeIPC(ctx, a, msg)
+			})
+		},
+	}
+}
+
+// WithTimeout - bounds the context passed to inbound handlers and outbound writes by duration
+func WithTimeout(duration time.Duration) Interceptor {
+	return Interceptor{
+		Inbound: func(next Handler) Handler {
+			return HandlerFunc(func(ctx context.Context, a *Actor, msg *Message) {
+				ctx, cancel := context.WithTimeout(ctx, duration)
+				defer cancel()
+				next.ServeIPC(ctx, a, msg)
+			})
+		},
+		Outbound: func(next WriteFunc) WriteFunc {
+			return func(ctx context.Context, a *Actor, msg *Message) error {
+				ctx, cancel := context.WithTimeout(ctx, duration)
+				defer cancel()
+				return next(ctx, a, msg)
+			}
+		},
+	}
+}
@@ -0,0 +1,344 @@
+package gipc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// JSON-RPC 2.0 error codes
+const (
+	JSONRPCParseError     = -32700
+	JSONRPCInvalidRequest = -32600
+	JSONRPCMethodNotFound = -32601
+	JSONRPCInvalidParams  = -32602
+	JSONRPCInternalError  = -32603
+	JSONRPCServerError    = -32000 // returned for handler errors which are not a *JSONRPCError
+)
+
+const jsonrpcVersion = "2.0"
+
+// JSONRPCError - the JSON-RPC 2.0 error object, handlers can return it to control the error code
+type JSONRPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+func (e *JSONRPCError) Error() string {
+	return fmt.Sprintf("jsonrpc error %d: %s", e.Code, e.Message)
+}
+
+// JSONRPCHandlerFunc - handles a JSON-RPC method call, params is null when omitted by the caller
+type JSONRPCHandlerFunc func(ctx context.Context, params json.RawMessage) (any, error)
+
+// JSONRPCServer - dispatches JSON-RPC 2.0 calls, batches and notifications to registered methods.
+// It implements Handler and is registered on a ServeMux for JSONRPC_MSGTYPE.
+type JSONRPCServer struct {
+	mutex   sync.RWMutex
+	methods map[string]JSONRPCHandlerFunc
+}
+
+// JSONRPCClient - calls the methods of a JSONRPCServer over a Client
+type JSONRPCClient struct {
+	Client *Client
+	lastId int64
+}
+
+// JSONRPCCall - a single call of a batch, Result is unmarshalled on success and Error is set on failure.
+// Notifications don't receive a response.
+type JSONRPCCall struct {
+	Method       string
+	Params       any
+	Result       any
+	Error        *JSONRPCError
+	Notification bool
+}
+
+type jsonrpcRequest struct {
+	Version string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	ID      json.RawMessage `json:"id,omitempty"`
+}
+
+type jsonrpcResponse struct {
+	Version string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *JSONRPCError   `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+func NewJSONRPCServer() *JSONRPCServer {
+	return &JSONRPCServer{methods: make(map[string]JSONRPCHandlerFunc)}
+}
+
+// Register - registers fn for the method name, replacing any existing method
+func (rs *JSONRPCServer) Register(method string, fn JSONRPCHandlerFunc) {
+	rs.mutex.Lock()
+	rs.methods[method] = fn
+	rs.mutex.Unlock()
+}
+
+// ServeIPC - handles the JSON-RPC payload of msg, replying when it contains at least one call
+func (rs *JSONRPCServer) ServeIPC(ctx context.Context, a *Actor, msg *Message) {
+
+	response := rs.Handle(ctx, msg.Data)
+	if response == nil {
+		//only notifications
+		return
+	}
+
+	var err error
+	if msg.IsRequest() {
+		err = a.ReplyContext(ctx, msg, response)
+	} else {
+		err = a.WriteContext(ctx, JSONRPC_MSGTYPE, response)
+	}
+
+	if err != nil {
+		a.logger.Errorf("%s.JSONRPCServer err: %s", a, err)
+	}
+}
+
+// Handle - processes a JSON-RPC request or batch returning the encoded response, which is nil
+// when there is nothing to respond with (notifications)
+func (rs *JSONRPCServer) Handle(ctx context.Context, payload []byte) []byte {
+
+	payload = bytes.TrimSpace(payload)
+
+	if len(payload) > 0 && payload[0] == '[' {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(payload, &batch); err != nil {
+			return encodeJSONRPC(newJSONRPCErrorResponse(nil, JSONRPCParseError, "Parse error"))
+		}
+		if len(batch) == 0 {
+			return encodeJSONRPC(newJSONRPCErrorResponse(nil, JSONRPCInvalidRequest, "Invalid Request"))
+		}
+
+		var responses []*jsonrpcResponse
+		for _, raw := range batch {
+			if response := rs.handleOne(ctx, raw); response != nil {
+				responses = append(responses, response)
+			}
+		}
+
+		if len(responses) == 0 {
+			return nil
+		}
+
+		return encodeJSONRPC(responses)
+	}
+
+	response := rs.handleOne(ctx, payload)
+	if response == nil {
+		return nil
+	}
+
+	return encodeJSONRPC(response)
+}
+
+func (rs *JSONRPCServer) handleOne(ctx context.Context, raw json.RawMessage) *jsonrpcResponse {
+
+	var req jsonrpcRequest
+	if err := json.Unmarshal(raw, &req); err != nil {
+		if _, isSyntaxErr := err.(*json.SyntaxError); isSyntaxErr {
+			return newJSONRPCErrorResponse(nil, JSONRPCParseError, "Parse error")
+		}
+		return newJSONRPCErrorResponse(nil, JSONRPCInvalidRequest, "Invalid Request")
+	}
+
+	if req.Version != jsonrpcVersion || len(req.Method) == 0 {
+		return newJSONRPCErrorResponse(req.ID, JSONRPCInvalidRequest, "Invalid Request")
+	}
+
+	isNotification := req.ID == nil
+
+	rs.mutex.RLock()
+	fn, ok := rs.methods[req.Method]
+	rs.mutex.RUnlock()
+
+	if !ok {
+		if isNotification {
+			return nil
+		}
+		return newJSONRPCErrorResponse(req.ID, JSONRPCMethodNotFound, "Method not found")
+	}
+
+	params := req.Params
+	if params == nil {
+		params = json.RawMessage("null")
+	}
+
+	result, err := callJSONRPC(ctx, fn, params)
+	if isNotification {
+		return nil
+	}
+
+	if err != nil {
+		var rpcErr *JSONRPCError
+		if errors.As(err, &rpcErr) {
+			return &jsonrpcResponse{Version: jsonrpcVersion, Error: rpcErr, ID: req.ID}
+		}
+		return newJSONRPCErrorResponse(req.ID, JSONRPCServerError, err.Error())
+	}
+
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		return newJSONRPCErrorResponse(req.ID, JSONRPCInternalError, err.Error())
+	}
+
+	return &jsonrpcResponse{Version: jsonrpcVersion, Result: encoded, ID: req.ID}
+}
+
+// callJSONRPC - calls the method converting a panic to an internal error
+func callJSONRPC(ctx context.Context, fn JSONRPCHandlerFunc, params json.RawMessage) (result any, err error) {
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = &JSONRPCError{Code: JSONRPCInternalError, Message: fmt.Sprintf("Internal error: %v", r)}
+		}
+	}()
+
+	return fn(ctx, params)
+}
+
+func newJSONRPCErrorResponse(id json.RawMessage, code int, message string) *jsonrpcResponse {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	return &jsonrpcResponse{Version: jsonrpcVersion, Error: &JSONRPCError{Code: code, Message: message}, ID: id}
+}
+
+func encodeJSONRPC(v any) []byte {
+	//responses only contain values which were already marshalled
+	b, _ := json.Marshal(v)
+	return b
+}
+
+func NewJSONRPCClient(c *Client) *JSONRPCClient {
+	return &JSONRPCClient{Client: c}
+}
+
+func (rc *JSONRPCClient) newRequest(method string, params any, notification bool) (*jsonrpcRequest, error) {
+
+	req := &jsonrpcRequest{Version: jsonrpcVersion, Method: method}
+
+	if params != nil {
+		encoded, err := json.Marshal(params)
+		if err != nil {
+			return nil, err
+		}
+		req.Params = encoded
+	}
+
+	if !notification {
+		req.ID = json.RawMessage(fmt.Sprintf("%d", atomic.AddInt64(&rc.lastId, 1)))
+	}
+
+	return req, nil
+}
+
+// Call - calls method with params unmarshalling the result into result, a failed call returns a *JSONRPCError
+func (rc *JSONRPCClient) Call(ctx context.Context, method string, params any, result any) error {
+
+	call := &JSONRPCCall{Method: method, Params: params, Result: result}
+
+	err := rc.Batch(ctx, []*JSONRPCCall{call})
+	if err != nil {
+		return err
+	}
+
+	if call.Error != nil {
+		return call.Error
+	}
+
+	return nil
+}
+
+// Notify - calls method with params without waiting for a response
+func (rc *JSONRPCClient) Notify(ctx context.Context, method string, params any) error {
+
+	req, err := rc.newRequest(method, params, true)
+	if err != nil {
+		return err
+	}
+
+	return rc.Client.WriteContext(ctx, JSONRPC_MSGTYPE, encodeJSONRPC(req))
+}
+
+// Batch - sends the calls as a single batch, setting the Result or Error of each call
+func (rc *JSONRPCClient) Batch(ctx context.Context, calls []*JSONRPCCall) error {
+
+	if len(calls) == 0 {
+		return errors.New("a batch must contain at least one call")
+	}
+
+	requests := make([]*jsonrpcRequest, len(calls))
+	byId := make(map[string]*JSONRPCCall, len(calls))
+
+	for i, call := range calls {
+		req, err := rc.newRequest(call.Method, call.Params, call.Notification)
+		if err != nil {
+			return err
+		}
+		requests[i] = req
+		if !call.Notification {
+			byId[string(req.ID)] = call
+		}
+	}
+
+	var payload []byte
+	if len(calls) == 1 {
+		payload = encodeJSONRPC(requests[0])
+	} else {
+		payload = encodeJSONRPC(requests)
+	}
+
+	if len(byId) == 0 {
+		return rc.Client.WriteContext(ctx, JSONRPC_MSGTYPE, payload)
+	}
+
+	reply, err := rc.Client.Request(ctx, JSONRPC_MSGTYPE, payload)
+	if err != nil {
+		return err
+	}
+
+	var responses []*jsonrpcResponse
+	data := bytes.TrimSpace(reply.Data)
+	if len(data) > 0 && data[0] == '[' {
+		err = json.Unmarshal(data, &responses)
+	} else {
+		var response jsonrpcResponse
+		err = json.Unmarshal(data, &response)
+		responses = append(responses, &response)
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, response := range responses {
+		call, ok := byId[string(response.ID)]
+		if !ok {
+			if response.Error != nil {
+				//the server could not identify the request (i.e. parse error)
+				return response.Error
+			}
+			continue
+		}
+
+		if response.Error != nil {
+			call.Error = response.Error
+		} else if call.Result != nil {
+			if err = json.Unmarshal(response.Result, call.Result); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
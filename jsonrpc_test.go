@@ -0,0 +1,138 @@
+package gipc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func newTestJSONRPCServer(notified chan string) *JSONRPCServer {
+
+	rs := NewJSONRPCServer()
+	rs.Register("subtract", func(ctx context.Context, params json.RawMessage) (any, error) {
+		var args []int
+		if err := json.Unmarshal(params, &args); err != nil || len(args) != 2 {
+			return nil, &JSONRPCError{Code: JSONRPCInvalidParams, Message: "Invalid params"}
+		}
+		return args[0] - args[1], nil
+	})
+	rs.Register("fail", func(ctx context.Context, params json.RawMessage) (any, error) {
+		return nil, errors.New("failed")
+	})
+	rs.Register("notify", func(ctx context.Context, params json.RawMessage) (any, error) {
+		var s string
+		json.Unmarshal(params, &s)
+		notified <- s
+		return nil, nil
+	})
+
+	return rs
+}
+
+func TestRPCJSONHandle(t *testing.T) {
+
+	rs := newTestJSONRPCServer(make(chan string, 10))
+	ctx := context.Background()
+
+	tests := []struct {
+		request string
+		want    string
+	}{
+		{`{"jsonrpc":"2.0","method":"subtract","params":[42,23],"id":1}`, `{"jsonrpc":"2.0","result":19,"id":1}`},
+		{`{"jsonrpc":"2.0","method":"subtract","params":[42],"id":"a"}`, `{"jsonrpc":"2.0","error":{"code":-32602,"message":"Invalid params"},"id":"a"}`},
+		{`{"jsonrpc":"2.0","method":"missing","id":2}`, `{"jsonrpc":"2.0","error":{"code":-32601,"message":"Method not found"},"id":2}`},
+		{`{"jsonrpc":"2.0","method":"fail","id":3}`, `{"jsonrpc":"2.0","error":{"code":-32000,"message":"failed"},"id":3}`},
+		{`{"jsonrpc":"2.0","method":"notify","params":"x"}`, ``},
+		{`{"jsonrpc":"2.0","method":"subtract","params":"bar","baz]`, `{"jsonrpc":"2.0","error":{"code":-32700,"message":"Parse error"},"id":null}`},
+		{`{"jsonrpc":"1.0","method":"subtract","id":4}`, `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request"},"id":4}`},
+		{`[]`, `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request"},"id":null}`},
+		{`[1]`, `[{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request"},"id":null}]`},
+		{`[{"jsonrpc":"2.0","method":"subtract","params":[1,1],"id":5},{"jsonrpc":"2.0","method":"notify","params":"y"}]`, `[{"jsonrpc":"2.0","result":0,"id":5}]`},
+		{`[{"jsonrpc":"2.0","method":"notify","params":"z"}]`, ``},
+	}
+
+	for _, test := range tests {
+		got := string(rs.Handle(ctx, []byte(test.request)))
+		if got != test.want {
+			t.Errorf("%s: Got %s, Wanted %s", test.request, got, test.want)
+		}
+	}
+}
+
+func TestRPCJSONClient(t *testing.T) {
+
+	Sleep()
+
+	notified := make(chan string, 1)
+
+	sc, err := StartServer(NewServerConfig("test_jsonrpc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sc.Close()
+
+	mux := NewServeMux()
+	mux.Handle(JSONRPC_MSGTYPE, newTestJSONRPCServer(notified))
+	go sc.Serve(mux)
+
+	Sleep()
+
+	cc, err := StartClient(NewClientConfig("test_jsonrpc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+
+	go func() {
+		for {
+			if _, err := cc.Read(); err != nil {
+				return
+			}
+		}
+	}()
+
+	rc := NewJSONRPCClient(cc)
+	ctx := context.Background()
+
+	var result int
+	if err = rc.Call(ctx, "subtract", []int{42, 23}, &result); err != nil {
+		t.Fatal(err)
+	}
+	if result != 19 {
+		t.Errorf("Got %d, Wanted %d", result, 19)
+	}
+
+	err = rc.Call(ctx, "missing", nil, nil)
+	var rpcErr *JSONRPCError
+	if !errors.As(err, &rpcErr) || rpcErr.Code != JSONRPCMethodNotFound {
+		t.Errorf("expected a method not found error but got: %v", err)
+	}
+
+	if err = rc.Notify(ctx, "notify", "hello"); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-notified; got != "hello" {
+		t.Errorf("Got %q, Wanted %q", got, "hello")
+	}
+
+	var first, second int
+	calls := []*JSONRPCCall{
+		{Method: "subtract", Params: []int{10, 1}, Result: &first},
+		{Method: "fail"},
+		{Method: "notify", Params: "batched", Notification: true},
+		{Method: "subtract", Params: []int{10, 2}, Result: &second},
+	}
+	if err = rc.Batch(ctx, calls); err != nil {
+		t.Fatal(err)
+	}
+	if first != 9 || second != 8 {
+		t.Errorf("Got %d and %d, Wanted 9 and 8", first, second)
+	}
+	if calls[1].Error == nil || calls[1].Error.Message != "failed" {
+		t.Errorf("expected the failed call to have an error but got: %v", calls[1].Error)
+	}
+	if got := <-notified; got != "batched" {
+		t.Errorf("Got %q, Wanted %q", got, "batched")
+	}
+}
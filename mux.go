@@ -0,0 +1,188 @@
+package gipc
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Handler - responds to a message received by an Actor
+type Handler interface {
+	ServeIPC(ctx context.Context, a *Actor, msg *Message)
+}
+
+// HandlerFunc - allows an ordinary function to be used as a Handler
+type HandlerFunc func(ctx context.Context, a *Actor, msg *Message)
+
+func (f HandlerFunc) ServeIPC(ctx context.Context, a *Actor, msg *Message) {
+	f(ctx, a, msg)
+}
+
+// StatusFunc - receives the status messages (MsgType == -1) and errors of an Actor
+type StatusFunc func(a *Actor, msg *Message)
+
+// ServeMux - dispatches each message to the Handler registered for its MsgType
+type ServeMux struct {
+	mutex    sync.RWMutex
+	handlers map[int]Handler
+	fallback Handler
+	status   StatusFunc
+}
+
+func NewServeMux() *ServeMux {
+	return &ServeMux{handlers: make(map[int]Handler)}
+}
+
+// Handle - registers the handler for msgType, replacing any existing handler
+func (mux *ServeMux) Handle(msgType int, handler Handler) {
+
+	if msgType <= 0 {
+		panic(fmt.Sprintf("gipc: invalid handler message type %d", msgType))
+	}
+
+	mux.mutex.Lock()
+	mux.handlers[msgType] = handler
+	mux.mutex.Unlock()
+}
+
+// HandleFunc - registers the handler function for msgType
+func (mux *ServeMux) HandleFunc(msgType int, handler func(ctx context.Context, a *Actor, msg *Message)) {
+	mux.Handle(msgType, HandlerFunc(handler))
+}
+
+// HandleFallback - registers the handler for messages without a registered MsgType
+func (mux *ServeMux) HandleFallback(handler Handler) {
+	mux.mutex.Lock()
+	mux.fallback = handler
+	mux.mutex.Unlock()
+}
+
+// HandleStatus - registers the lifecycle callback receiving status messages, errors and handler panics
+func (mux *ServeMux) HandleStatus(status StatusFunc) {
+	mux.mutex.Lock()
+	mux.status = status
+	mux.mutex.Unlock()
+}
+
+// Handler - returns the handler for msgType, the fallback handler if none is registered
+func (mux *ServeMux) Handler(msgType int) Handler {
+	mux.mutex.RLock()
+	defer mux.mutex.RUnlock()
+
+	if handler, ok := mux.handlers[msgType]; ok {
+		return handler
+	}
+
+	return mux.fallback
+}
+
+// ServeIPC - dispatches msg to the handler registered for its MsgType
+func (mux *ServeMux) ServeIPC(ctx context.Context, a *Actor, msg *Message) {
+
+	handler := mux.Handler(msg.MsgType)
+	if handler == nil {
+		a.logger.Warnf("%s.Serve - no handler registered for message type %d", a, msg.MsgType)
+		return
+	}
+
+	handler.ServeIPC(ctx, a, msg)
+}
+
+func (mux *ServeMux) dispatchStatus(a *Actor, msg *Message) {
+	mux.mutex.RLock()
+	status := mux.status
+	mux.mutex.RUnlock()
+
+	if status != nil {
+		status(a, msg)
+	}
+}
+
+// dispatch - calls the handler recovering from any panic so the serve loop keeps running
+func (mux *ServeMux) dispatch(ctx context.Context, a *Actor, msg *Message, handler Handler) {
+
+	defer func() {
+		if r := recover(); r != nil {
+			err := fmt.Errorf("handler for message type %d panicked: %v", msg.MsgType, r)
+			a.logger.Errorf("%s.Serve err: %s", a, err)
+			mux.dispatchStatus(a, &Message{Err: err, MsgType: -1})
+		}
+	}()
+
+	handler.ServeIPC(ctx, a, msg)
+}
+
+// serve - reads and dispatches messages until ctx is done or the connection is closed
+func (a *Actor) serve(ctx context.Context, mux *ServeMux) error {
+
+	handler := a.inboundChain(mux)
+
+	for {
+		var msg *Message
+		var err error
+
+		select {
+		case m, ok := <-a.received:
+			msg, err = a.readMessage(m, ok)
+		case <-a.failed:
+			err = errReceivedChannelClosed
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-a.closed:
+			return nil
+		}
+
+		if err != nil {
+			if err == errReceivedChannelClosed {
+				return err
+			}
+			mux.dispatchStatus(a, &Message{Err: err, MsgType: -1})
+			if !a.config.IsServer || a.getStatus() == Closed {
+				//the client stops reading after an error
+				return err
+			}
+			continue
+		}
+
+		if msg.MsgType == -1 {
+			mux.dispatchStatus(a, msg)
+			continue
+		}
+
+		mux.dispatch(ctx, a, msg, handler)
+	}
+}
+
+// Serve - dispatches every message received to mux until the server is closed.
+// In MultiClient mode every pool connection, including those created later, is served.
+func (s *Server) Serve(mux *ServeMux) error {
+	return s.ServeContext(context.Background(), mux)
+}
+
+// ServeContext - dispatches every message received to mux until ctx is done or the server is closed
+func (s *Server) ServeContext(ctx context.Context, mux *ServeMux) error {
+
+	if s.Connections == nil {
+		return s.serve(ctx, mux)
+	}
+
+	//the servers added after ServeContext returns would otherwise be served by every past call
+	unregister := s.Connections.forEachServer(func(ps *Server) {
+		if ps != s {
+			go ps.serve(ctx, mux)
+		}
+	})
+	defer unregister()
+
+	return s.serve(ctx, mux)
+}
+
+// Serve - dispatches every message received to mux until the client is closed
+func (c *Client) Serve(mux *ServeMux) error {
+	return c.ServeContext(context.Background(), mux)
+}
+
+// ServeContext - dispatches every message received to mux until ctx is done or the client is closed
+func (c *Client) ServeContext(ctx context.Context, mux *ServeMux) error {
+	return c.serve(ctx, mux)
+}
@@ -0,0 +1,349 @@
+package gipc
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMuxServe(t *testing.T) {
+
+	Sleep()
+
+	sc, err := StartServer(NewServerConfig("test_mux"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sc.Close()
+
+	panicked := make(chan bool, 1)
+	mux := NewServeMux()
+	mux.HandleFunc(5, func(ctx context.Context, a *Actor, msg *Message) {
+		a.Reply(msg, append([]byte("echo "), msg.Data...))
+	})
+	mux.HandleFunc(6, func(ctx context.Context, a *Actor, msg *Message) {
+		panic("handler failure")
+	})
+	mux.HandleFallback(HandlerFunc(func(ctx context.Context, a *Actor, msg *Message) {
+		a.Reply(msg, []byte("unknown"))
+	}))
+	mux.HandleStatus(func(a *Actor, msg *Message) {
+		if msg.Err != nil && strings.Contains(msg.Err.Error(), "panicked: handler failure") {
+			panicked <- true
+		}
+	})
+
+	go sc.Serve(mux)
+
+	Sleep()
+
+	cc, err := StartClient(NewClientConfig("test_mux"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+
+	connected := make(chan bool, 1)
+	cmux := NewServeMux()
+	cmux.HandleStatus(func(a *Actor, msg *Message) {
+		if msg.Status == "Connected" {
+			connected <- true
+		}
+	})
+	go cc.Serve(cmux)
+
+	<-connected
+
+	reply, err := cc.Request(context.Background(), 5, []byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(reply.Data) != "echo hello" {
+		t.Errorf("Got %q, Wanted %q", reply.Data, "echo hello")
+	}
+
+	cc.Write(6, []byte("boom"))
+	<-panicked
+
+	//the serve loop should survive the panic and dispatch to the fallback handler
+	reply, err = cc.Request(context.Background(), 7, []byte("?"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(reply.Data) != "unknown" {
+		t.Errorf("Got %q, Wanted %q", reply.Data, "unknown")
+	}
+}
+
+func TestMuxServeMultiClient(t *testing.T) {
+
+	Sleep()
+
+	scon := NewServerConfig("test_mux_multi")
+	scon.MultiClient = true
+	sc, err := StartServer(scon)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sc.Close()
+
+	mux := NewServeMux()
+	mux.HandleFunc(5, func(ctx context.Context, a *Actor, msg *Message) {
+		a.Reply(msg, msg.Data)
+	})
+	go sc.Serve(mux)
+
+	Sleep()
+
+	for _, name := range []string{"first", "second", "third"} {
+		ccon := NewClientConfig("test_mux_multi")
+		ccon.MultiClient = true
+		cc, err := StartClient(ccon)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer cc.Close()
+
+		go func() {
+			for {
+				if _, err := cc.Read(); err != nil {
+					return
+				}
+			}
+		}()
+
+		reply, err := cc.Request(context.Background(), 5, []byte(name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(reply.Data) != name {
+			t.Errorf("Got %q, Wanted %q", reply.Data, name)
+		}
+	}
+}
+
+func TestMuxInterceptors(t *testing.T) {
+
+	Sleep()
+
+	var order []string
+	var mutex sync.Mutex
+	record := func(s string) {
+		mutex.Lock()
+		order = append(order, s)
+		mutex.Unlock()
+	}
+	tracer := func(name string) Interceptor {
+		return Interceptor{
+			Inbound: func(next Handler) Handler {
+				return HandlerFunc(func(ctx context.Context, a *Actor, msg *Message) {
+					record("in:" + name)
+					next.ServeIPC(ctx, a, msg)
+				})
+			},
+		}
+	}
+	//rejects messages which are not authorized and decorates replies
+	authorize := Interceptor{
+		Inbound: func(next Handler) Handler {
+			return HandlerFunc(func(ctx context.Context, a *Actor, msg *Message) {
+				if msg.MsgType == 9 {
+					a.Reply(msg, []byte("forbidden"))
+					return
+				}
+				next.ServeIPC(ctx, a, msg)
+			})
+		},
+		Outbound: func(next WriteFunc) WriteFunc {
+			return func(ctx context.Context, a *Actor, msg *Message) error {
+				msg.Data = append([]byte("server: "), msg.Data...)
+				return next(ctx, a, msg)
+			}
+		},
+	}
+
+	scon := NewServerConfig("test_mux_interceptors")
+	scon.Interceptors = []Interceptor{WithRecover(nil), WithLogging(nil), WithTimeout(time.Second), tracer("first"), tracer("second"), authorize}
+	sc, err := StartServer(scon)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sc.Close()
+
+	mux := NewServeMux()
+	mux.HandleFunc(5, func(ctx context.Context, a *Actor, msg *Message) {
+		if _, ok := ctx.Deadline(); !ok {
+			t.Error("WithTimeout should have set a deadline on the handler context")
+		}
+		record("handler")
+		a.Reply(msg, msg.Data)
+	})
+	mux.HandleFunc(9, func(ctx context.Context, a *Actor, msg *Message) {
+		t.Error("the unauthorized message should not reach its handler")
+	})
+	go sc.Serve(mux)
+
+	Sleep()
+
+	cc, err := StartClient(NewClientConfig("test_mux_interceptors"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+
+	go func() {
+		for {
+			if _, err := cc.Read(); err != nil {
+				return
+			}
+		}
+	}()
+
+	reply, err := cc.Request(context.Background(), 5, []byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(reply.Data) != "server: hello" {
+		t.Errorf("Got %q, Wanted %q", reply.Data, "server: hello")
+	}
+
+	reply, err = cc.Request(context.Background(), 9, []byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(reply.Data) != "server: forbidden" {
+		t.Errorf("Got %q, Wanted %q", reply.Data, "server: forbidden")
+	}
+
+	mutex.Lock()
+	got := strings.Join(order, ",")
+	mutex.Unlock()
+	want := "in:first,in:second,handler,in:first,in:second"
+	if got != want {
+		t.Errorf("Got %q, Wanted %q", got, want)
+	}
+}
+
+func TestMuxServeMultiClientUnregister(t *testing.T) {
+
+	Sleep()
+
+	scon := NewServerConfig("test_mux_unregister")
+	scon.MultiClient = true
+	sc, err := StartServer(scon)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sc.Close()
+
+	mux := NewServeMux()
+	mux.HandleFunc(5, func(ctx context.Context, a *Actor, msg *Message) {
+		a.Reply(msg, msg.Data)
+	})
+
+	//every returned ServeContext stops serving the servers added later
+	for i := 0; i < 3; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		sc.ServeContext(ctx, mux)
+		cancel()
+	}
+
+	sc.Connections.mutex.Lock()
+	callbacks := len(sc.Connections.onAdd)
+	sc.Connections.mutex.Unlock()
+	if callbacks != 0 {
+		t.Errorf("Got %d callbacks, Wanted 0", callbacks)
+	}
+
+	go sc.Serve(mux)
+
+	Sleep()
+
+	ccon := NewClientConfig("test_mux_unregister")
+	ccon.MultiClient = true
+	cc, err := StartClient(ccon)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+	drainClient(cc)
+
+	reply, err := cc.Request(context.Background(), 5, []byte("served"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(reply.Data) != "served" {
+		t.Errorf("Got %q, Wanted %q", reply.Data, "served")
+	}
+}
+
+func TestMuxInterceptorsRead(t *testing.T) {
+
+	Sleep()
+
+	//drops every message of type 9, whichever consumer reads it
+	authorize := Interceptor{
+		Inbound: func(next Handler) Handler {
+			return HandlerFunc(func(ctx context.Context, a *Actor, msg *Message) {
+				if msg.MsgType == 9 {
+					return
+				}
+				next.ServeIPC(ctx, a, msg)
+			})
+		},
+	}
+	panics := Interceptor{
+		Inbound: func(next Handler) Handler {
+			return HandlerFunc(func(ctx context.Context, a *Actor, msg *Message) {
+				if string(msg.Data) == "panic" {
+					panic("interceptor panic")
+				}
+				next.ServeIPC(ctx, a, msg)
+			})
+		},
+	}
+
+	scon := NewServerConfig("test_mux_interceptors_read")
+	scon.Interceptors = []Interceptor{panics, authorize}
+	sc, cc := startConfiguredPair(t, scon, NewClientConfig("test_mux_interceptors_read"))
+	defer sc.Close()
+	defer cc.Close()
+
+	writes := []*Message{{MsgType: 9, Data: []byte("hello")}, {MsgType: 5, Data: []byte("panic")}, {MsgType: 5, Data: []byte("hello")}}
+	for _, m := range writes {
+		if err := cc.WriteMessage(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	msg, err := sc.ReadTimed(time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.MsgType != 5 || string(msg.Data) != "hello" {
+		t.Errorf("Got type %d %q, Wanted type 5 %q: the dropped messages should not be read", msg.MsgType, msg.Data, "hello")
+	}
+
+	//the messages delivered to a conn pass through the interceptors as well
+	sconn, err := sc.AsConn(9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cconn, err := cc.AsConn(9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = cconn.Write([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+
+	sconn.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+	buf := make([]byte, 16)
+	if n, err := sconn.Read(buf); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("Got %q %v, Wanted the conn data to be dropped", buf[:n], err)
+	}
+}
@@ -0,0 +1,246 @@
+package gipc
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// LeaderFunc - called each time a Peer becomes the leader or a follower, including when it starts
+type LeaderFunc func(p *Peer, leader bool)
+
+// PeerConfig - used to pass configuration overrides to StartPeer()
+type PeerConfig struct {
+	Name          string
+	LeaderChanged LeaderFunc    // called each time the Peer becomes the leader or a follower
+	RetryTimer    time.Duration // the duration to wait before contending again when neither role could be taken (default is 1 second)
+	Timeout       time.Duration // the duration a follower waits for the leader to accept its connection (default is 5 seconds)
+	Server        *ServerConfig // overrides the config of the leader, Name and MultiClient are always set
+	Client        *ClientConfig // overrides the config of the followers, must match Server
+}
+
+// Peer - one of several processes contending for the same name. The leader runs the server pool
+// and every follower is a pool client connected to it. When the connection of a follower to the
+// leader is lost the followers contend again: the first to take the name becomes the new leader
+// and the others connect to it.
+type Peer struct {
+	config *PeerConfig
+	mutex  sync.Mutex
+	server *Server
+	client *Client
+	lost   chan *Client // the follower client which lost its connection, reported once per client
+	closed chan struct{}
+	once   sync.Once
+}
+
+// StartPeer - contends for the name, becoming the leader when it is free and a follower of the
+// leader otherwise
+func StartPeer(config *PeerConfig) (*Peer, error) {
+
+	err := checkIpcName(config.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Peer{
+		config: config,
+		lost:   make(chan *Client, 1),
+		closed: make(chan struct{}),
+	}
+
+	if err = p.contend(); err != nil {
+		return nil, err
+	}
+
+	go p.watch()
+
+	return p, nil
+}
+
+// IsLeader - whether the Peer is currently running the server pool
+func (p *Peer) IsLeader() bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.server != nil
+}
+
+// Server - the server pool of the leader, nil when the Peer is a follower
+func (p *Peer) Server() *Server {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.server
+}
+
+// Client - the pool client connected to the leader, nil when the Peer is the leader
+func (p *Peer) Client() *Client {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.client
+}
+
+// Close - closes the server or client of the Peer, a leader releases the name to the followers
+func (p *Peer) Close() {
+
+	p.once.Do(func() {
+		close(p.closed)
+	})
+
+	p.mutex.Lock()
+	server, client := p.server, p.client
+	p.server, p.client = nil, nil
+	p.mutex.Unlock()
+
+	if server != nil {
+		server.Close()
+	}
+	if client != nil {
+		client.Close()
+	}
+}
+
+func (p *Peer) isClosed() bool {
+	select {
+	case <-p.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+func (p *Peer) retryTimer() time.Duration {
+	if p.config.RetryTimer <= 0 {
+		return time.Second
+	}
+	return p.config.RetryTimer
+}
+
+func (p *Peer) serverConfig() *ServerConfig {
+	scon := &ServerConfig{Encryption: ENCRYPT_BY_DEFAULT}
+	if p.config.Server != nil {
+		sc := *p.config.Server
+		scon = &sc
+	}
+	scon.Name = p.config.Name
+	scon.MultiClient = true
+	return scon
+}
+
+func (p *Peer) clientConfig() *ClientConfig {
+	ccon := &ClientConfig{Encryption: ENCRYPT_BY_DEFAULT}
+	if p.config.Client != nil {
+		cc := *p.config.Client
+		ccon = &cc
+	}
+	ccon.Name = p.config.Name
+	ccon.MultiClient = true
+	if ccon.Timeout <= 0 {
+		ccon.Timeout = p.config.Timeout
+		if ccon.Timeout <= 0 {
+			ccon.Timeout = 5 * time.Second
+		}
+	}
+	return ccon
+}
+
+// contend - takes the name or connects to the leader owning it. The leader can die between both
+// attempts, in which case the Peer contends again.
+func (p *Peer) contend() error {
+
+	for {
+		s, err := StartServer(p.serverConfig())
+		if err == nil {
+			p.setRole(s, nil)
+			return nil
+		} else if !errors.Is(err, ErrAddressInUse) {
+			return err
+		}
+
+		c, err := StartClient(p.clientConfig())
+		if err == nil {
+			p.follow(c)
+			p.setRole(nil, c)
+			return nil
+		}
+
+		if p.isClosed() {
+			return errors.New("gipc: the peer has been closed")
+		}
+
+		time.Sleep(p.retryTimer())
+	}
+}
+
+// follow - reports c to watch once its connection to the leader is lost or closed
+func (p *Peer) follow(c *Client) {
+
+	var once sync.Once
+	lost := func(status Status) {
+		if status <= Connected {
+			return
+		}
+		once.Do(func() {
+			select {
+			case p.lost <- c:
+			case <-p.closed:
+			}
+		})
+	}
+
+	c.onStatus(lost)
+	//the connection may have been lost before the hook was added
+	lost(c.getStatus())
+}
+
+// setRole - keeps the server or client of the new role, unless the Peer was closed meanwhile
+func (p *Peer) setRole(s *Server, c *Client) {
+
+	p.mutex.Lock()
+	if p.isClosed() {
+		p.mutex.Unlock()
+		if s != nil {
+			s.Close()
+		}
+		if c != nil {
+			c.Close()
+		}
+		return
+	}
+	p.server, p.client = s, c
+	p.mutex.Unlock()
+
+	if p.config.LeaderChanged != nil {
+		p.config.LeaderChanged(p, s != nil)
+	}
+}
+
+// watch - contends again each time the follower loses its connection to the leader
+func (p *Peer) watch() {
+
+	for {
+		var c *Client
+		select {
+		case <-p.closed:
+			return
+		case c = <-p.lost:
+		}
+
+		p.mutex.Lock()
+		current := p.client == c
+		if current {
+			p.client = nil
+		}
+		p.mutex.Unlock()
+
+		if !current {
+			continue
+		}
+
+		//the client would otherwise keep reconnecting to the pool server of the previous leader
+		c.Close()
+
+		//the errors of StartServer and StartClient are logged by them
+		for !p.isClosed() && p.contend() != nil {
+			time.Sleep(p.retryTimer())
+		}
+	}
+}
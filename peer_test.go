@@ -0,0 +1,258 @@
+package gipc
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+// peerEchoConfig - a PeerConfig whose leader replies to requests of message type 5 with its id
+func peerEchoConfig(name string, id string, changes chan string) *PeerConfig {
+
+	mux := NewServeMux()
+	mux.HandleFunc(5, func(ctx context.Context, a *Actor, msg *Message) {
+		a.Reply(msg, []byte(id))
+	})
+
+	return &PeerConfig{
+		Name:       name,
+		RetryTimer: 50 * time.Millisecond,
+		LeaderChanged: func(p *Peer, leader bool) {
+			if leader {
+				go p.Server().Serve(mux)
+			} else {
+				drainClient(p.Client())
+			}
+			if changes != nil {
+				changes <- fmt.Sprintf("%s:%t", id, leader)
+			}
+		},
+	}
+}
+
+// waitForLeader - waits until exactly one of peers is the leader, returns it and the followers
+func waitForLeader(t *testing.T, peers ...*Peer) (*Peer, []*Peer) {
+
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		var leader *Peer
+		var followers []*Peer
+		for _, p := range peers {
+			if p.IsLeader() {
+				leader = p
+			} else if c := p.Client(); c != nil && c.getStatus() == Connected {
+				followers = append(followers, p)
+			}
+		}
+		if leader != nil && len(followers) == len(peers)-1 {
+			return leader, followers
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatal("no leader was elected")
+	return nil, nil
+}
+
+// requestLeader - the id of the leader replying to the request of the follower
+func requestLeader(t *testing.T, follower *Peer) string {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	reply, err := follower.Client().Request(ctx, 5, []byte("who"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(reply.Data)
+}
+
+func TestPeerLeaderElection(t *testing.T) {
+
+	Sleep()
+
+	changes := make(chan string, 10)
+	p1, err := StartPeer(peerEchoConfig("test_peer", "p1", changes))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p1.Close()
+
+	if !p1.IsLeader() || p1.Server() == nil || p1.Client() != nil {
+		t.Fatal("the first peer should be the leader")
+	}
+	if change := <-changes; change != "p1:true" {
+		t.Errorf("Got %q, Wanted %q", change, "p1:true")
+	}
+
+	p2, err := StartPeer(peerEchoConfig("test_peer", "p2", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p2.Close()
+
+	p3, err := StartPeer(peerEchoConfig("test_peer", "p3", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p3.Close()
+
+	_, followers := waitForLeader(t, p1, p2, p3)
+	for _, f := range followers {
+		if got := requestLeader(t, f); got != "p1" {
+			t.Errorf("Got %q, Wanted %q", got, "p1")
+		}
+	}
+
+	//a follower leaving doesn't change the leader
+	p3.Close()
+	if got := requestLeader(t, p2); got != "p1" {
+		t.Errorf("Got %q, Wanted %q", got, "p1")
+	}
+
+	//the leader leaving promotes the follower
+	p1.Close()
+	leader, _ := waitForLeader(t, p2)
+	if leader != p2 {
+		t.Error("the follower should be promoted")
+	}
+}
+
+func TestPeerFollowerMessages(t *testing.T) {
+
+	Sleep()
+
+	p1, err := StartPeer(&PeerConfig{Name: "test_peer_messages"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p1.Close()
+
+	p2, err := StartPeer(&PeerConfig{Name: "test_peer_messages"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p2.Close()
+
+	waitForLeader(t, p1, p2)
+
+	//every message type is read by the follower, the loss of the leader is detected without a route
+	for _, msgType := range []int{17, 18, 19} {
+		if err = p1.Server().Write(msgType, []byte("leader")); err != nil {
+			t.Fatal(err)
+		}
+
+		for {
+			m, err := p2.Client().ReadTimed(5 * time.Second)
+			if err != nil || m == TimeoutMessage {
+				t.Fatalf("the follower should read message type %d: %v", msgType, err)
+			}
+			if m.MsgType == msgType {
+				break
+			}
+		}
+	}
+}
+
+// TestPeerHelperProcess - the leader started as a separate process by TestPeerLeaderKilled
+func TestPeerHelperProcess(t *testing.T) {
+
+	name := os.Getenv("GIPC_PEER_HELPER")
+	if name == "" {
+		return
+	}
+
+	p, err := StartPeer(&PeerConfig{Name: name})
+	if err != nil || !p.IsLeader() {
+		fmt.Println("follower", err)
+		os.Exit(1)
+	}
+	fmt.Println("leader")
+
+	select {}
+}
+
+func TestPeerLeaderKilled(t *testing.T) {
+
+	Sleep()
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestPeerHelperProcess$")
+	cmd.Env = append(os.Environ(), "GIPC_PEER_HELPER=test_peer_killed")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer cmd.Process.Kill()
+
+	line, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil || line != "leader\n" {
+		t.Fatalf("the helper process should be the leader, got %q %v", line, err)
+	}
+
+	changes := make(chan string, 10)
+
+	p2, err := StartPeer(peerEchoConfig("test_peer_killed", "p2", changes))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p2.Close()
+
+	p3, err := StartPeer(peerEchoConfig("test_peer_killed", "p3", changes))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p3.Close()
+
+	for i := 0; i < 2; i++ {
+		if change := <-changes; change != "p2:false" && change != "p3:false" {
+			t.Errorf("unexpected leadership change %q", change)
+		}
+	}
+	if p2.IsLeader() || p3.IsLeader() {
+		t.Fatal("the helper process should remain the leader")
+	}
+
+	clients := map[*Client]bool{p2.Client(): true, p3.Client(): true}
+
+	//the kernel closes the sockets of the killed leader without the server pool closing them
+	if err = cmd.Process.Kill(); err != nil {
+		t.Fatal(err)
+	}
+	cmd.Wait()
+
+	//both followers notice the loss of the leader before their new roles are checked
+	for clients[p2.Client()] || clients[p3.Client()] {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	leader, followers := waitForLeader(t, p2, p3)
+
+	id := "p2"
+	if leader == p3 {
+		id = "p3"
+	}
+
+	//the promotion is reported to the new leader, the other follower only reconnects
+	for change := ""; change != id+":true"; {
+		select {
+		case change = <-changes:
+			if strings.HasSuffix(change, ":true") && change != id+":true" {
+				t.Errorf("unexpected leadership change %q", change)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("the LeaderChanged callback of %s should report its promotion", id)
+		}
+	}
+
+	//the other follower reconnected to the new leader
+	if got := requestLeader(t, followers[0]); got != id {
+		t.Errorf("Got %q, Wanted %q", got, id)
+	}
+}
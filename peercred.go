@@ -0,0 +1,86 @@
+package gipc
+
+import (
+	"errors"
+	"fmt"
+	"net"
+)
+
+// ErrPeerCredentialsUnavailable - returned when the credentials of the peer can't be read, they are
+// only available for unix socket connections on linux
+var ErrPeerCredentialsUnavailable = errors.New("gipc: the peer credentials are unavailable")
+
+// PeerCredentials - the process on the other end of a unix socket, as reported by the kernel
+// when the connection was accepted
+type PeerCredentials struct {
+	PID int
+	UID int
+	GID int
+}
+
+func (pc *PeerCredentials) String() string {
+	return fmt.Sprintf("pid=%d uid=%d gid=%d", pc.PID, pc.UID, pc.GID)
+}
+
+// AuthorizeFunc - called with the credentials of each accepted connection before the handshake,
+// creds is nil when they are unavailable. Returning an error rejects the connection.
+type AuthorizeFunc func(creds *PeerCredentials) error
+
+// PeerCredentials - returns the credentials of the connected client. In MultiClient mode each
+// client is connected to one of the servers of the Connections pool.
+func (s *Server) PeerCredentials() (*PeerCredentials, error) {
+	s.mutex.Lock()
+	creds := s.peerCreds
+	s.mutex.Unlock()
+
+	if creds == nil {
+		return nil, ErrPeerCredentialsUnavailable
+	}
+
+	return creds, nil
+}
+
+// authorize - reads the credentials of conn and passes them to the Authorize hook of the config,
+// they are kept for PeerCredentials once the hook has accepted the peer
+func (s *Server) authorize(conn net.Conn) error {
+
+	//the credentials of the previous client are never reported for a rejected peer
+	s.setPeerCredentials(nil)
+
+	creds, err := getPeerCredentials(conn)
+	if err != nil {
+		s.logger.Debugf("%s.authorize - %s", s, err)
+		creds = nil
+	}
+
+	if err = s.callAuthorize(creds); err != nil {
+		return err
+	}
+
+	s.setPeerCredentials(creds)
+
+	return nil
+}
+
+// setPeerCredentials - creds is nil once the connection of the client is lost
+func (s *Server) setPeerCredentials(creds *PeerCredentials) {
+	s.mutex.Lock()
+	s.peerCreds = creds
+	s.mutex.Unlock()
+}
+
+// callAuthorize - a panic of the Authorize hook rejects the peer instead of ending the accept loop
+func (s *Server) callAuthorize(creds *PeerCredentials) (err error) {
+
+	if s.config.ServerConfig.Authorize == nil {
+		return nil
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("the Authorize hook panicked: %v", r)
+		}
+	}()
+
+	return s.config.ServerConfig.Authorize(creds)
+}
@@ -0,0 +1,35 @@
+//go:build linux
+
+package gipc
+
+import (
+	"net"
+	"syscall"
+)
+
+// getPeerCredentials - reads SO_PEERCRED of a unix socket connection
+func getPeerCredentials(conn net.Conn) (*PeerCredentials, error) {
+
+	uc, ok := conn.(*net.UnixConn)
+	if !ok {
+		return nil, ErrPeerCredentialsUnavailable
+	}
+
+	raw, err := uc.SyscallConn()
+	if err != nil {
+		return nil, err
+	}
+
+	var ucred *syscall.Ucred
+	var credErr error
+	err = raw.Control(func(fd uintptr) {
+		ucred, credErr = syscall.GetsockoptUcred(int(fd), syscall.SOL_SOCKET, syscall.SO_PEERCRED)
+	})
+	if err != nil {
+		return nil, err
+	} else if credErr != nil {
+		return nil, credErr
+	}
+
+	return &PeerCredentials{PID: int(ucred.Pid), UID: int(ucred.Uid), GID: int(ucred.Gid)}, nil
+}
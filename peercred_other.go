@@ -0,0 +1,12 @@
+//go:build !linux
+
+package gipc
+
+import (
+	"net"
+)
+
+// getPeerCredentials - SO_PEERCRED is only read on linux
+func getPeerCredentials(conn net.Conn) (*PeerCredentials, error) {
+	return nil, ErrPeerCredentialsUnavailable
+}
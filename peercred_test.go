@@ -0,0 +1,200 @@
+//go:build linux && !network
+
+package gipc
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestUnixPeerCredentials(t *testing.T) {
+
+	Sleep()
+
+	authorized := make(chan *PeerCredentials, 1)
+	scon := NewServerConfig("test_peercred")
+	scon.Authorize = func(creds *PeerCredentials) error {
+		authorized <- creds
+		return nil
+	}
+	sc, err := StartServer(scon)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sc.Close()
+
+	if _, err = sc.PeerCredentials(); err != ErrPeerCredentialsUnavailable {
+		t.Errorf("expected ErrPeerCredentialsUnavailable before a client connects but got: %v", err)
+	}
+
+	Sleep()
+
+	cc, err := StartClient(NewClientConfig("test_peercred"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+
+	creds := <-authorized
+	if creds == nil || creds.PID != os.Getpid() || creds.UID != os.Getuid() || creds.GID != os.Getgid() {
+		t.Errorf("unexpected credentials passed to Authorize: %v", creds)
+	}
+
+	waitForConnected(t, &sc.Actor)
+	if got, err := sc.PeerCredentials(); err != nil || *got != *creds {
+		t.Errorf("Got %v %v, Wanted %v", got, err, creds)
+	}
+}
+
+func TestUnixPeerCredentialsDisconnected(t *testing.T) {
+
+	Sleep()
+
+	sc, cc := startConnPair(t, "test_peercred_disconnected")
+	defer sc.Close()
+
+	if _, err := sc.PeerCredentials(); err != nil {
+		t.Fatal(err)
+	}
+
+	cc.Close()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for sc.StatusCode() != Disconnected && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if sc.StatusCode() != Disconnected {
+		t.Fatalf("the server should be disconnected but is %s", sc.Status())
+	}
+
+	if creds, err := sc.PeerCredentials(); err != ErrPeerCredentialsUnavailable {
+		t.Errorf("expected ErrPeerCredentialsUnavailable once the client disconnected but got: %v %v", creds, err)
+	}
+}
+
+func TestUnixPeerCredentialsRejected(t *testing.T) {
+
+	Sleep()
+
+	scon := NewServerConfig("test_peercred_rejected")
+	scon.Authorize = func(creds *PeerCredentials) error {
+		if creds != nil && creds.UID == os.Getuid() {
+			return errors.New("uid not allowed")
+		}
+		return nil
+	}
+	sc, err := StartServer(scon)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sc.Close()
+
+	Sleep()
+
+	ccon := NewClientConfig("test_peercred_rejected")
+	ccon.Timeout = time.Second * 2
+	if _, err = StartClient(ccon); err != ErrRejected {
+		t.Errorf("expected ErrRejected but got: %v", err)
+	}
+
+	if sc.StatusCode() != Listening {
+		t.Errorf("the server should still be listening but is %s", sc.Status())
+	}
+
+	//the credentials of a rejected peer are not kept
+	if _, err = sc.PeerCredentials(); err != ErrPeerCredentialsUnavailable {
+		t.Errorf("expected ErrPeerCredentialsUnavailable but got: %v", err)
+	}
+}
+
+func TestUnixPeerCredentialsAuthorizePanic(t *testing.T) {
+
+	Sleep()
+
+	calls := 0
+	scon := NewServerConfig("test_peercred_panic")
+	scon.Authorize = func(creds *PeerCredentials) error {
+		calls++
+		if calls == 1 {
+			panic("authorize failed")
+		}
+		return nil
+	}
+	sc, err := StartServer(scon)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sc.Close()
+
+	Sleep()
+
+	ccon := NewClientConfig("test_peercred_panic")
+	ccon.Timeout = time.Second * 2
+	if _, err = StartClient(ccon); err != ErrRejected {
+		t.Errorf("expected ErrRejected but got: %v", err)
+	}
+
+	//the server keeps accepting connections after the panic
+	cc, err := StartClient(ccon)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+
+	waitForConnected(t, &cc.Actor)
+
+	if _, err = sc.PeerCredentials(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestUnixPeerCredentialsMultiClient(t *testing.T) {
+
+	Sleep()
+
+	scon := NewServerConfig("test_peercred_multi")
+	scon.MultiClient = true
+	sc, err := StartServer(scon)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sc.Close()
+
+	mux := NewServeMux()
+	mux.HandleFunc(5, func(ctx context.Context, a *Actor, msg *Message) {
+		a.Reply(msg, msg.Data)
+	})
+	go sc.Serve(mux)
+
+	Sleep()
+
+	for i := 0; i < 2; i++ {
+		ccon := NewClientConfig("test_peercred_multi")
+		ccon.MultiClient = true
+		cc, err := StartClient(ccon)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer cc.Close()
+		drainClient(cc)
+
+		if _, err = cc.Request(context.Background(), 5, []byte("ping")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	//every client server of the pool has the credentials of its own client
+	servers := sc.Connections.getServers()
+	if len(servers) != 3 {
+		t.Fatalf("Got %d servers, Wanted 3", len(servers))
+	}
+	for _, ps := range servers[1:] {
+		creds, err := ps.PeerCredentials()
+		if err != nil || creds.PID != os.Getpid() {
+			t.Errorf("unexpected credentials %v %v", creds, err)
+		}
+	}
+}
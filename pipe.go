@@ -0,0 +1,208 @@
+package gipc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// pipe - the byte stream shared by conns and streams. The data received from the remote side never
+// exceeds the window granted to it, the local writer waits for the window granted by the remote side.
+type pipe struct {
+	mutex         sync.Mutex
+	buff          []byte
+	readable      chan struct{}
+	writable      chan struct{}
+	sendWindow    int
+	consumed      int   // bytes read since the last window update
+	readErr       error // returned by Read once the buffered data is read
+	closed        bool
+	readDeadline  time.Time
+	writeDeadline time.Time
+}
+
+func newPipe() *pipe {
+	return &pipe{
+		readable:   make(chan struct{}, 1),
+		writable:   make(chan struct{}, 1),
+		sendWindow: streamInitialWindow,
+	}
+}
+
+// receive - buffers data from the read loop, returns false instead when the remote side exceeded
+// its window
+func (p *pipe) receive(data []byte) bool {
+
+	p.mutex.Lock()
+	overflow := len(p.buff)+len(data) > streamInitialWindow
+	if !overflow && p.readErr == nil {
+		p.buff = append(p.buff, data...)
+	}
+	p.mutex.Unlock()
+
+	if !overflow {
+		signal(p.readable)
+	}
+	return !overflow
+}
+
+// grant - adds the window granted by the remote side
+func (p *pipe) grant(increment int) {
+	p.mutex.Lock()
+	p.sendWindow += increment
+	p.mutex.Unlock()
+	signal(p.writable)
+}
+
+// read - waits for data, the read error or the read deadline. update is the window to grant the
+// remote side, once half of it has been read.
+func (p *pipe) read(b []byte) (n int, update int, err error) {
+
+	for {
+		p.mutex.Lock()
+		if p.closed {
+			p.mutex.Unlock()
+			return 0, 0, net.ErrClosed
+		}
+		if len(p.buff) > 0 {
+			n = copy(b, p.buff)
+			p.buff = p.buff[n:]
+			p.consumed += n
+			if p.consumed >= streamInitialWindow/2 && p.readErr == nil {
+				update = p.consumed
+				p.consumed = 0
+			}
+			p.mutex.Unlock()
+			return n, update, nil
+		}
+		if p.readErr != nil {
+			err = p.readErr
+			p.mutex.Unlock()
+			return 0, 0, err
+		}
+		deadline := p.readDeadline
+		p.mutex.Unlock()
+
+		if err = waitSignal(p.readable, deadline); err != nil {
+			return 0, 0, err
+		}
+	}
+}
+
+// write - splits b into frames of at most chunkSize bytes and the remote window, waiting while the
+// window is exhausted. check is called with the mutex held, returning an error stops the write.
+func (p *pipe) write(b []byte, chunkSize int, check func() error, writeFrame func(ctx context.Context, chunk []byte) error) (int, error) {
+
+	ctx, cancel := p.writeContext()
+	defer cancel()
+
+	written := 0
+	for written < len(b) {
+		p.mutex.Lock()
+		if err := check(); err != nil {
+			p.mutex.Unlock()
+			return written, err
+		}
+		if p.sendWindow == 0 {
+			deadline := p.writeDeadline
+			p.mutex.Unlock()
+
+			if err := waitSignal(p.writable, deadline); err != nil {
+				return written, err
+			}
+			continue
+		}
+
+		n := min(len(b)-written, p.sendWindow, chunkSize)
+		p.sendWindow -= n
+		p.mutex.Unlock()
+
+		//the writer keeps a reference to the data until it is sent
+		chunk := make([]byte, n)
+		copy(chunk, b[written:written+n])
+
+		if err := writeFrame(ctx, chunk); err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				return written, os.ErrDeadlineExceeded
+			}
+			return written, err
+		}
+		written += n
+	}
+
+	return written, nil
+}
+
+func (p *pipe) writeContext() (context.Context, context.CancelFunc) {
+	p.mutex.Lock()
+	deadline := p.writeDeadline
+	p.mutex.Unlock()
+
+	if deadline.IsZero() {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithDeadline(context.Background(), deadline)
+}
+
+// wake - wakes up a blocked Read and Write, i.e. once the pipe is closed or failed
+func (p *pipe) wake() {
+	signal(p.readable)
+	signal(p.writable)
+}
+
+func (p *pipe) SetDeadline(t time.Time) error {
+	p.SetReadDeadline(t)
+	return p.SetWriteDeadline(t)
+}
+
+func (p *pipe) SetReadDeadline(t time.Time) error {
+	p.mutex.Lock()
+	p.readDeadline = t
+	p.mutex.Unlock()
+	//wake up a blocked Read so the new deadline is applied
+	signal(p.readable)
+	return nil
+}
+
+func (p *pipe) SetWriteDeadline(t time.Time) error {
+	p.mutex.Lock()
+	p.writeDeadline = t
+	p.mutex.Unlock()
+	signal(p.writable)
+	return nil
+}
+
+// signal - wakes up a goroutine waiting in waitSignal without blocking
+func signal(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+// waitSignal - waits for ch to be signalled, returning os.ErrDeadlineExceeded once deadline passes
+func waitSignal(ch chan struct{}, deadline time.Time) error {
+
+	if deadline.IsZero() {
+		<-ch
+		return nil
+	}
+
+	wait := time.Until(deadline)
+	if wait <= 0 {
+		return os.ErrDeadlineExceeded
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-ch:
+		return nil
+	case <-timer.C:
+		return os.ErrDeadlineExceeded
+	}
+}
@@ -1,10 +1,14 @@
 package gipc
 
 import (
+	"context"
 	"sync"
 	"time"
 )
 
+// poolManagerSuffix - added to the name of the server listening for new clients in MultiClient mode
+const poolManagerSuffix = "_manager"
+
 var clientIdRequest = &Message{
 	Data:    []byte("client_id_request"),
 	MsgType: CLIENT_CONNECT_MSGTYPE,
@@ -20,7 +24,7 @@ func StartServerPool(config *ServerConfig) (*Server, error) {
 	configName := config.Name
 
 	//creates a server exclusively for listening for new connections
-	cms, err := NewServer(configName+"_manager", config)
+	cms, err := NewServer(configName+poolManagerSuffix, config)
 	if err != nil {
 		return nil, err
 	}
@@ -32,6 +36,7 @@ func StartServerPool(config *ServerConfig) (*Server, error) {
 	//create another server for the user to interface
 	s, err := NewServer(configName, config)
 	if err != nil {
+		cms.close()
 		return nil, err
 	}
 	s.Connections = &ConnectionPool{
@@ -41,9 +46,16 @@ func StartServerPool(config *ServerConfig) (*Server, error) {
 		mutex:        &sync.Mutex{},
 	}
 
+	s, err = s.run(1)
+	if err != nil {
+		//the manager would otherwise keep listening and holding the lock of its socket
+		cms.close()
+		return s, err
+	}
+
 	go connectionListener(cms, s)
 
-	return s.run(1)
+	return s, nil
 }
 
 func connectionListener(cms *Server, s *Server) {
@@ -78,25 +90,27 @@ func connectionListener(cms *Server, s *Server) {
 
 			go ns.run(clientCount)
 			clientCount++
-			s.Connections.mutex.Lock()
-			s.Connections.Servers = append(s.Connections.Servers, ns)
-			s.Connections.mutex.Unlock()
+			s.Connections.add(ns)
 		}
 	}
 }
 
 func StartClientPool(config *ClientConfig) (*Client, error) {
+	return startClientPool(context.Background(), config)
+}
+
+func startClientPool(ctx context.Context, config *ClientConfig) (*Client, error) {
 
 	//copy to prevent modification of the reference
 	configName := config.Name
 
-	cm, err := NewClient(configName+"_manager", config)
+	cm, err := NewClient(configName+poolManagerSuffix, config)
 	if err != nil {
 		return nil, err
 	}
 	defer cm.Close()
 
-	cm, err = start(cm)
+	cm, err = start(ctx, cm)
 	if err != nil {
 		return nil, err
 	}
@@ -106,14 +120,20 @@ func StartClientPool(config *ClientConfig) (*Client, error) {
 		return nil, err
 	}
 
-	for {
-		message, err2 := cm.ReadTimed(5 * time.Second)
+	//the manager can die before replying, in which case its connection times out instead
+	idCtx := ctx
+	if cm.timeout != 0 {
+		var cancel context.CancelFunc
+		idCtx, cancel = context.WithTimeout(ctx, cm.timeout)
+		defer cancel()
+	}
 
-		if message == TimeoutMessage {
-			continue
-		} else if err2 != nil {
-			cm.logger.Debugf("StartClientPool err: %s", err)
-			continue
+	for {
+		message, err2 := cm.ReadContext(idCtx)
+		if err2 != nil {
+			//the client stops reading once its connection failed
+			cm.logger.Debugf("StartClientPool err: %s", err2)
+			return nil, err2
 		} else if message.MsgType != CLIENT_CONNECT_MSGTYPE {
 			continue
 		}
@@ -129,7 +149,7 @@ func StartClientPool(config *ClientConfig) (*Client, error) {
 				return nil, err3
 			}
 			cc.ClientId = clientId
-			return start(cc)
+			return start(ctx, cc)
 		}
 	}
 }
@@ -141,6 +161,48 @@ func (sm *ConnectionPool) getServers() []*Server {
 	return servers
 }
 
+func (sm *ConnectionPool) add(s *Server) {
+	sm.mutex.Lock()
+	sm.Servers = append(sm.Servers, s)
+	onAdd := make([]func(*Server), 0, len(sm.onAdd))
+	for _, callback := range sm.onAdd {
+		onAdd = append(onAdd, callback)
+	}
+	sm.mutex.Unlock()
+
+	for _, callback := range onAdd {
+		callback(s)
+	}
+}
+
+// forEachServer - calls callback for every client server in the pool, including servers added
+// later until the returned func is called
+func (sm *ConnectionPool) forEachServer(callback func(*Server)) func() {
+	sm.mutex.Lock()
+	servers := sm.Servers
+	if sm.onAdd == nil {
+		sm.onAdd = make(map[int]func(*Server))
+	}
+	id := sm.nextAdd
+	sm.nextAdd++
+	sm.onAdd[id] = callback
+	sm.mutex.Unlock()
+
+	for i, server := range servers {
+		//skip the first serverManager instance
+		if i == 0 {
+			continue
+		}
+		callback(server)
+	}
+
+	return func() {
+		sm.mutex.Lock()
+		delete(sm.onAdd, id)
+		sm.mutex.Unlock()
+	}
+}
+
 func (sm *ConnectionPool) MapExec(callback func(*Server), from string) {
 	servers := sm.getServers()
 	serverLen := len(servers)
@@ -1,4 +1,4 @@
-//go:build network && randomize_ports
+//go:build randomize_ports
 
 package gipc
 
@@ -0,0 +1,154 @@
+package gipc
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrRequestAborted - returned to in-flight requests when the connection is lost or closed
+var ErrRequestAborted = errors.New("request aborted: the connection was lost")
+
+// RemoteError - returned by Request when the remote side replied with ReplyError
+type RemoteError struct {
+	MsgType int
+	Message string
+}
+
+func (e *RemoteError) Error() string {
+	return e.Message
+}
+
+// pendingRequests - the in-flight requests awaiting a reply, keyed by correlation id
+type pendingRequests struct {
+	mutex    sync.Mutex
+	lastId   uint32
+	requests map[uint32]chan *Message
+}
+
+func newPendingRequests() *pendingRequests {
+	return &pendingRequests{requests: make(map[uint32]chan *Message)}
+}
+
+func (p *pendingRequests) add() (uint32, chan *Message) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for {
+		p.lastId++
+		//0 is reserved for messages without a correlation id
+		if _, ok := p.requests[p.lastId]; p.lastId != 0 && !ok {
+			break
+		}
+	}
+
+	replyChan := make(chan *Message, 1)
+	p.requests[p.lastId] = replyChan
+
+	return p.lastId, replyChan
+}
+
+func (p *pendingRequests) remove(id uint32) {
+	p.mutex.Lock()
+	delete(p.requests, id)
+	p.mutex.Unlock()
+}
+
+// resolve - delivers the reply to the waiting request, returns false if nothing is waiting
+func (p *pendingRequests) resolve(m *Message) bool {
+	p.mutex.Lock()
+	replyChan, ok := p.requests[m.id]
+	delete(p.requests, m.id)
+	p.mutex.Unlock()
+
+	if ok {
+		replyChan <- m
+	}
+
+	return ok
+}
+
+// abort - fails every in-flight request
+func (p *pendingRequests) abort(err error) {
+	p.mutex.Lock()
+	requests := p.requests
+	p.requests = make(map[uint32]chan *Message)
+	p.mutex.Unlock()
+
+	for _, replyChan := range requests {
+		replyChan <- &Message{Err: err, MsgType: -1}
+	}
+}
+
+// Request - writes a message and blocks until the reply carrying the same correlation id
+// is received, ctx is done or the connection is lost. Concurrent requests are allowed.
+func (a *Actor) Request(ctx context.Context, msgType int, data []byte) (*Message, error) {
+	return a.request(ctx, &Message{MsgType: msgType, Data: data})
+}
+
+// request - writes msg with a new correlation id and waits for its reply
+func (a *Actor) request(ctx context.Context, msg *Message) (*Message, error) {
+
+	id, replyChan := a.pending.add()
+	defer a.pending.remove(id)
+
+	msg.id = id
+	msg.flags |= flagRequest
+
+	err := a.writeMessageContext(ctx, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	if msg.written != nil {
+		//the writer still holds the message, e.g. the file of its segment, until it reports
+		defer func() { <-msg.written }()
+	}
+
+	select {
+	case reply := <-replyChan:
+		if reply.Err != nil {
+			return nil, reply.Err
+		}
+		if reply.flags&flagError != 0 {
+			return nil, &RemoteError{MsgType: reply.MsgType, Message: string(reply.Data)}
+		}
+		return reply, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Reply - writes data as the reply to req, which must have been received as a Request
+func (a *Actor) Reply(req *Message, data []byte) error {
+	return a.ReplyContext(context.Background(), req, data)
+}
+
+// ReplyContext - writes data as the reply to req, abandoning the write when ctx is cancelled
+func (a *Actor) ReplyContext(ctx context.Context, req *Message, data []byte) error {
+
+	if !req.IsRequest() {
+		err := errors.New("cannot reply to a message which is not a request")
+		a.logger.Errorf("%s.Reply err: %s", a, err)
+		return err
+	}
+
+	return a.writeMessageContext(ctx, &Message{MsgType: req.MsgType, Data: data, id: req.id, flags: flagReply})
+}
+
+// ReplyError - replies to req with an error, which is returned by Request as a *RemoteError
+func (a *Actor) ReplyError(req *Message, replyErr error) error {
+
+	if !req.IsRequest() {
+		err := errors.New("cannot reply to a message which is not a request")
+		a.logger.Errorf("%s.ReplyError err: %s", a, err)
+		return err
+	}
+
+	return a.writeMessageContext(context.Background(), &Message{MsgType: req.MsgType, Data: []byte(replyErr.Error()), id: req.id, flags: flagReply | flagError})
+}
+
+// IsRequest - returns true if the sender is waiting for a Reply to this message
+func (m *Message) IsRequest() bool {
+	return m.flags&flagRequest != 0
+}
@@ -0,0 +1,140 @@
+package gipc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func waitForConnected(t testing.TB, a *Actor) {
+	for {
+		m, err := a.Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.Status == "Connected" {
+			return
+		}
+	}
+}
+
+func TestRequestReply(t *testing.T) {
+
+	Sleep()
+
+	sc, err := StartServer(NewServerConfig("test_request"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sc.Close()
+
+	Sleep()
+
+	cc, err := StartClient(NewClientConfig("test_request"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+
+	waitForConnected(t, &cc.Actor)
+
+	go func() {
+		for {
+			m, err := sc.Read()
+			if err != nil {
+				return
+			}
+			if m.IsRequest() {
+				//reply out of order to exercise the correlation ids
+				go func(req *Message) {
+					if string(req.Data) == "slow" {
+						time.Sleep(time.Millisecond * 100)
+					}
+					sc.Reply(req, append([]byte("reply to "), req.Data...))
+				}(m)
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			data := fmt.Sprintf("request %d", i)
+			if i == 0 {
+				data = "slow"
+			}
+			reply, err := cc.Request(context.Background(), 5, []byte(data))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if string(reply.Data) != "reply to "+data {
+				t.Errorf("Got %q, Wanted %q", reply.Data, "reply to "+data)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*20)
+	defer cancel()
+	_, err = cc.Request(ctx, 5, []byte("slow"))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded but got: %s", err)
+	}
+
+	if err = sc.Reply(&Message{MsgType: 5}, nil); err == nil {
+		t.Error("replying to a message which is not a request should fail")
+	}
+}
+
+func TestRequestAbortedOnDisconnect(t *testing.T) {
+
+	Sleep()
+
+	sc, err := StartServer(NewServerConfig("test_request_abort"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	Sleep()
+
+	cc, err := StartClient(NewClientConfig("test_request_abort"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+
+	waitForConnected(t, &cc.Actor)
+
+	go func() {
+		for {
+			m, err := sc.Read()
+			if err != nil {
+				return
+			}
+			//never reply, close the connection instead
+			if m.IsRequest() {
+				sc.Close()
+				return
+			}
+		}
+	}()
+
+	go func() {
+		for {
+			if _, err := cc.Read(); err != nil {
+				return
+			}
+		}
+	}()
+
+	_, err = cc.Request(context.Background(), 5, []byte("abandoned"))
+	if !errors.Is(err, ErrRequestAborted) {
+		t.Errorf("expected ErrRequestAborted but got: %s", err)
+	}
+}
@@ -0,0 +1,85 @@
+package gipc
+
+import (
+	"sync"
+)
+
+// route - receives the messages of a message type instead of the received channel
+type route interface {
+	// deliver - called from the read loop, must not block
+	deliver(msg *Message)
+	// connectionLost - called when the connection is lost or closed
+	connectionLost()
+}
+
+// routeTable - the routes of an Actor keyed by message type
+type routeTable struct {
+	mutex  sync.Mutex
+	routes map[int]route
+}
+
+func newRouteTable() *routeTable {
+	return &routeTable{routes: make(map[int]route)}
+}
+
+// add - registers r for msgType, returns false if the message type is already routed
+func (rt *routeTable) add(msgType int, r route) bool {
+	rt.mutex.Lock()
+	defer rt.mutex.Unlock()
+
+	if _, ok := rt.routes[msgType]; ok {
+		return false
+	}
+	rt.routes[msgType] = r
+
+	return true
+}
+
+// remove - unregisters r if it is still the route for msgType
+func (rt *routeTable) remove(msgType int, r route) {
+	rt.mutex.Lock()
+	if rt.routes[msgType] == r {
+		delete(rt.routes, msgType)
+	}
+	rt.mutex.Unlock()
+}
+
+// lookup - returns the route of msgType, nil if the message type isn't routed
+func (rt *routeTable) lookup(msgType int) route {
+	rt.mutex.Lock()
+	defer rt.mutex.Unlock()
+
+	return rt.routes[msgType]
+}
+
+func (rt *routeTable) connectionLost() {
+	rt.mutex.Lock()
+	routes := make([]route, 0, len(rt.routes))
+	for _, r := range rt.routes {
+		routes = append(routes, r)
+	}
+	rt.mutex.Unlock()
+
+	for _, r := range routes {
+		r.connectionLost()
+	}
+}
+
+// connectionLost - fails the in-flight requests and routes when the connection is lost or closed
+func (a *Actor) connectionLost() {
+	if a.pending != nil {
+		a.pending.abort(ErrRequestAborted)
+	}
+	if a.routes != nil {
+		a.routes.connectionLost()
+	}
+	if a.streams != nil {
+		a.streams.connectionLost()
+	}
+	if a.fragments != nil {
+		a.fragments.reset()
+	}
+	if a.fds != nil {
+		a.fds.reset()
+	}
+}
@@ -0,0 +1,239 @@
+package gipc
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"io"
+	"net/rpc"
+	"sync"
+)
+
+// rpcServerCodec - implements rpc.ServerCodec over the messages of a Server, each request and
+// response is a single message containing the gob encoded header followed by the body
+type rpcServerCodec struct {
+	server  *Server
+	decoder *gob.Decoder
+	closed  bool
+	mutex   sync.Mutex
+}
+
+// rpcClientCodec - implements rpc.ClientCodec over the messages of a Client
+type rpcClientCodec struct {
+	client      *Client
+	decoder     *gob.Decoder
+	mutex       sync.Mutex
+	outstanding map[uint64]bool
+	aborted     []uint64
+}
+
+// NewRPCServerCodec - returns a net/rpc ServerCodec reading requests from the Server
+func NewRPCServerCodec(s *Server) rpc.ServerCodec {
+	return &rpcServerCodec{server: s}
+}
+
+// NewRPCClientCodec - returns a net/rpc ClientCodec writing requests to the Client.
+// Calls in flight when the connection is lost fail with ErrRequestAborted instead of hanging.
+func NewRPCClientCodec(c *Client) rpc.ClientCodec {
+	return &rpcClientCodec{client: c, outstanding: make(map[uint64]bool)}
+}
+
+// NewRPCClient - returns a net/rpc Client calling the services registered on the other end of c
+func NewRPCClient(c *Client) *rpc.Client {
+	return rpc.NewClientWithCodec(NewRPCClientCodec(c))
+}
+
+// ServeRPC - serves the services registered on server over s until it is closed.
+// In MultiClient mode every pool connection, including those created later, is served.
+func ServeRPC(server *rpc.Server, s *Server) {
+
+	if s.Connections == nil {
+		server.ServeCodec(NewRPCServerCodec(s))
+		return
+	}
+
+	unregister := s.Connections.forEachServer(func(ps *Server) {
+		if ps != s {
+			go server.ServeCodec(NewRPCServerCodec(ps))
+		}
+	})
+	defer unregister()
+
+	server.ServeCodec(NewRPCServerCodec(s))
+}
+
+func encodeRPC(header any, body any) ([]byte, error) {
+
+	var buff bytes.Buffer
+	encoder := gob.NewEncoder(&buff)
+
+	err := encoder.Encode(header)
+	if err != nil {
+		return nil, err
+	}
+
+	err = encoder.Encode(body)
+	if err != nil {
+		return nil, err
+	}
+
+	return buff.Bytes(), nil
+}
+
+func (sc *rpcServerCodec) ReadRequestHeader(r *rpc.Request) error {
+
+	for {
+		msg, err := sc.server.readUntilClosed()
+		if err != nil {
+			if err == errReceivedChannelClosed || sc.isClosed() || sc.server.getStatus() >= Closed {
+				return io.EOF
+			}
+			sc.server.logger.Debugf("%s.ReadRequestHeader err: %s", sc.server, err)
+			continue
+		}
+
+		if msg.MsgType != RPC_MSGTYPE {
+			if msg.MsgType != -1 {
+				sc.server.logger.Warnf("%s.ReadRequestHeader discarding message type %d", sc.server, msg.MsgType)
+			}
+			continue
+		}
+
+		sc.decoder = gob.NewDecoder(bytes.NewReader(msg.Data))
+
+		return sc.decoder.Decode(r)
+	}
+}
+
+func (sc *rpcServerCodec) ReadRequestBody(body any) error {
+	if body == nil {
+		//the body is discarded along with the message
+		return nil
+	}
+	return sc.decoder.Decode(body)
+}
+
+func (sc *rpcServerCodec) WriteResponse(r *rpc.Response, body any) error {
+
+	data, err := encodeRPC(r, body)
+	if err != nil {
+		return err
+	}
+
+	return sc.server.Write(RPC_MSGTYPE, data)
+}
+
+func (sc *rpcServerCodec) isClosed() bool {
+	sc.mutex.Lock()
+	defer sc.mutex.Unlock()
+	return sc.closed
+}
+
+func (sc *rpcServerCodec) Close() error {
+	sc.mutex.Lock()
+	sc.closed = true
+	sc.mutex.Unlock()
+	sc.server.Close()
+	return nil
+}
+
+func (cc *rpcClientCodec) WriteRequest(r *rpc.Request, body any) error {
+
+	data, err := encodeRPC(r, body)
+	if err != nil {
+		return err
+	}
+
+	cc.mutex.Lock()
+	cc.outstanding[r.Seq] = true
+	cc.mutex.Unlock()
+
+	err = cc.client.Write(RPC_MSGTYPE, data)
+	if err != nil {
+		cc.mutex.Lock()
+		delete(cc.outstanding, r.Seq)
+		cc.mutex.Unlock()
+	}
+
+	return err
+}
+
+// abortOutstanding - queues an error response for every call in flight
+func (cc *rpcClientCodec) abortOutstanding() {
+	cc.mutex.Lock()
+	for seq := range cc.outstanding {
+		cc.aborted = append(cc.aborted, seq)
+	}
+	cc.outstanding = make(map[uint64]bool)
+	cc.mutex.Unlock()
+}
+
+func (cc *rpcClientCodec) nextAborted() (uint64, bool) {
+	cc.mutex.Lock()
+	defer cc.mutex.Unlock()
+
+	if len(cc.aborted) == 0 {
+		return 0, false
+	}
+
+	seq := cc.aborted[0]
+	cc.aborted = cc.aborted[1:]
+
+	return seq, true
+}
+
+func (cc *rpcClientCodec) ReadResponseHeader(r *rpc.Response) error {
+
+	for {
+		if seq, ok := cc.nextAborted(); ok {
+			cc.decoder = nil
+			r.Seq = seq
+			r.Error = ErrRequestAborted.Error()
+			return nil
+		}
+
+		msg, err := cc.client.Read()
+		if err != nil {
+			return io.EOF
+		}
+
+		if msg.MsgType == -1 {
+			if msg.Status == ReConnecting.String() || msg.Status == Disconnected.String() {
+				cc.abortOutstanding()
+			}
+			continue
+		} else if msg.MsgType != RPC_MSGTYPE {
+			cc.client.logger.Warnf("%s.ReadResponseHeader discarding message type %d", cc.client, msg.MsgType)
+			continue
+		}
+
+		cc.decoder = gob.NewDecoder(bytes.NewReader(msg.Data))
+
+		err = cc.decoder.Decode(r)
+		if err != nil {
+			return err
+		}
+
+		cc.mutex.Lock()
+		delete(cc.outstanding, r.Seq)
+		cc.mutex.Unlock()
+
+		return nil
+	}
+}
+
+func (cc *rpcClientCodec) ReadResponseBody(body any) error {
+	if body == nil {
+		//the body is discarded along with the message
+		return nil
+	}
+	if cc.decoder == nil {
+		return errors.New("rpc response has no body")
+	}
+	return cc.decoder.Decode(body)
+}
+
+func (cc *rpcClientCodec) Close() error {
+	cc.client.Close()
+	return nil
+}
@@ -0,0 +1,89 @@
+package gipc
+
+import (
+	"errors"
+	"net/rpc"
+	"testing"
+	"time"
+)
+
+type RPCArith struct {
+	block chan bool
+}
+
+type RPCArgs struct {
+	A, B int
+}
+
+func (a *RPCArith) Multiply(args *RPCArgs, reply *int) error {
+	*reply = args.A * args.B
+	return nil
+}
+
+func (a *RPCArith) Divide(args *RPCArgs, reply *int) error {
+	if args.B == 0 {
+		return errors.New("divide by zero")
+	}
+	*reply = args.A / args.B
+	return nil
+}
+
+func (a *RPCArith) Block(args *RPCArgs, reply *int) error {
+	a.block <- true
+	time.Sleep(time.Second)
+	return nil
+}
+
+func TestRPCCodec(t *testing.T) {
+
+	Sleep()
+
+	arith := &RPCArith{block: make(chan bool, 1)}
+	server := rpc.NewServer()
+	if err := server.Register(arith); err != nil {
+		t.Fatal(err)
+	}
+
+	sc, err := StartServer(NewServerConfig("test_rpc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	go ServeRPC(server, sc)
+
+	Sleep()
+
+	cc, err := StartClient(NewClientConfig("test_rpc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := NewRPCClient(cc)
+	defer client.Close()
+
+	var product int
+	if err = client.Call("RPCArith.Multiply", &RPCArgs{A: 6, B: 7}, &product); err != nil {
+		t.Fatal(err)
+	}
+	if product != 42 {
+		t.Errorf("Got %d, Wanted %d", product, 42)
+	}
+
+	var quotient int
+	err = client.Call("RPCArith.Divide", &RPCArgs{A: 1, B: 0}, &quotient)
+	if err == nil || err.Error() != "divide by zero" {
+		t.Errorf("expected the service error but got: %v", err)
+	}
+
+	//the call in flight when the server goes away should fail instead of hanging
+	call := client.Go("RPCArith.Block", &RPCArgs{}, &quotient, nil)
+	<-arith.block
+	sc.Close()
+
+	select {
+	case <-call.Done:
+		if call.Error == nil || call.Error.Error() != ErrRequestAborted.Error() {
+			t.Errorf("expected ErrRequestAborted but got: %v", call.Error)
+		}
+	case <-time.After(time.Second * 5):
+		t.Error("the call in flight should have been aborted")
+	}
+}
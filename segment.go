@@ -0,0 +1,73 @@
+package gipc
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"os"
+)
+
+// fileSegment - a part of a file written after the Data of a message, so bulk file payloads
+// aren't copied into the message. Without encryption it is written with sendfile when possible.
+type fileSegment struct {
+	file   *os.File
+	offset int64
+	length int
+}
+
+// dataLen - the length of the message data including its file segment
+func (m *Message) dataLen() int {
+	if m.segment == nil {
+		return len(m.Data)
+	}
+	return len(m.Data) + m.segment.length
+}
+
+// encodeFrameSegment - encodes the frame with the file segment read into it
+func encodeFrameSegment(m *Message) ([]byte, error) {
+
+	frame := encodeFrame(m)
+	if m.segment == nil {
+		return frame, nil
+	}
+
+	frame = append(frame, make([]byte, m.segment.length)...)
+	_, err := m.segment.file.ReadAt(frame[len(frame)-m.segment.length:], m.segment.offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return frame, nil
+}
+
+// writeSegment - writes the size, the frame and then the file segment without copying it through
+// user space when the connection supports it. Encryption must be disabled.
+func (a *Actor) writeSegment(conn net.Conn, writer *bufio.Writer, m *Message) {
+
+	frame := encodeFrame(m)
+
+	_, err := writer.Write(intToBytes(len(frame) + m.segment.length))
+	if err == nil {
+		_, err = writer.Write(frame)
+	}
+	if err == nil {
+		err = writer.Flush()
+	}
+	if err != nil {
+		a.logger.Errorf("%s error writing message: %s", a, err)
+		m.reportWritten(err)
+		return
+	}
+
+	handled, err := sendFileSegment(conn, m.segment)
+	if !handled && err == nil {
+		_, err = io.Copy(conn, io.NewSectionReader(m.segment.file, m.segment.offset, int64(m.segment.length)))
+	}
+
+	if err != nil {
+		//the size was already written so the framing can't be recovered
+		a.logger.Errorf("%s error writing file segment, closing the connection: %s", a, err)
+		conn.Close()
+	}
+	m.reportWritten(err)
+}
@@ -0,0 +1,68 @@
+//go:build linux
+
+package gipc
+
+import (
+	"errors"
+	"io"
+	"net"
+	"syscall"
+)
+
+// sendFileSegment - writes the segment to a unix socket with sendfile, returns false when the
+// connection isn't a unix socket (i.e. a wrapped or network transport) so the caller copies it
+func sendFileSegment(conn net.Conn, seg *fileSegment) (bool, error) {
+
+	uc, ok := conn.(*net.UnixConn)
+	if !ok {
+		return false, nil
+	}
+
+	sockConn, err := uc.SyscallConn()
+	if err != nil {
+		return false, nil
+	}
+	fileConn, err := seg.file.SyscallConn()
+	if err != nil {
+		return false, nil
+	}
+
+	offset := seg.offset
+	remaining := seg.length
+	var sendErr, writeErr error
+
+	err = fileConn.Control(func(fileFd uintptr) {
+		writeErr = sockConn.Write(func(sockFd uintptr) bool {
+			for remaining > 0 {
+				n, err := syscall.Sendfile(int(sockFd), int(fileFd), &offset, remaining)
+				if n > 0 {
+					remaining -= n
+				}
+				if errors.Is(err, syscall.EAGAIN) {
+					//wait until the socket is writable
+					return false
+				} else if errors.Is(err, syscall.EINTR) {
+					continue
+				} else if err != nil {
+					sendErr = err
+					return true
+				} else if n == 0 {
+					sendErr = io.ErrUnexpectedEOF
+					return true
+				}
+			}
+			return true
+		})
+	})
+
+	if sendErr != nil && remaining == seg.length && (errors.Is(sendErr, syscall.EINVAL) || errors.Is(sendErr, syscall.ENOSYS)) {
+		//sendfile isn't supported for this file, nothing was written yet
+		return false, nil
+	} else if sendErr != nil {
+		return true, sendErr
+	} else if writeErr != nil {
+		return true, writeErr
+	}
+
+	return true, err
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+
+package gipc
+
+import (
+	"net"
+)
+
+// sendFileSegment - sendfile is only used on linux, the caller copies the segment
+func sendFileSegment(conn net.Conn, seg *fileSegment) (bool, error) {
+	return false, nil
+}
@@ -0,0 +1,180 @@
+package gipc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// copyConn - hides the *net.UnixConn so file segments are copied instead of sent with sendfile
+type copyConn struct {
+	net.Conn
+}
+
+func copyTransport() Transport {
+	return WrapTransport(nativeTransport(), func(conn net.Conn) net.Conn {
+		return copyConn{conn}
+	})
+}
+
+// startSegmentPair - starts a server and a client with encryption and the transport of the benchmark case
+func startSegmentPair(tb testing.TB, name string, encryption bool, transport Transport) (*Server, *Client) {
+
+	scon := NewServerConfig(name)
+	scon.Encryption = encryption
+	scon.Transport = transport
+
+	ccon := NewClientConfig(name)
+	ccon.Encryption = encryption
+	ccon.Transport = transport
+
+	return startConfiguredPair(tb, scon, ccon)
+}
+
+func TestFileTransferSegments(t *testing.T) {
+
+	dir := t.TempDir()
+	data := writeTestFile(t, dir, "segment", fileChunkSize)
+
+	f, err := os.Open(filepath.Join(dir, "segment"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	cases := []struct {
+		name       string
+		encryption bool
+		transport  Transport
+	}{
+		{"sendfile", false, nil},
+		{"copy", false, copyTransport()},
+		{"encrypted", true, nil},
+	}
+
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+
+			Sleep()
+
+			sc, cc := startSegmentPair(t, "test_segment_"+test.name, test.encryption, test.transport)
+			defer sc.Close()
+			defer cc.Close()
+
+			msg := &Message{MsgType: 5, Data: []byte("prefix"), segment: &fileSegment{file: f, offset: 10, length: len(data) - 20}}
+			if err := cc.writeMessageContext(context.Background(), msg); err != nil {
+				t.Fatal(err)
+			}
+			cc.Write(6, []byte("next"))
+
+			m, err := sc.Read()
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := append([]byte("prefix"), data[10:len(data)-10]...)
+			if m.MsgType != 5 || !bytes.Equal(m.Data, want) {
+				t.Errorf("Got type %d of %d bytes, Wanted type 5 of %d bytes", m.MsgType, len(m.Data), len(want))
+			}
+
+			//the framing is intact after the segment
+			if m, err = sc.Read(); err != nil || string(m.Data) != "next" {
+				t.Errorf("expected the next message but got: %v %v", m, err)
+			}
+		})
+	}
+}
+
+func TestFileTransferSegmentReadError(t *testing.T) {
+
+	Sleep()
+
+	dir := t.TempDir()
+	writeTestFile(t, dir, "short", 100)
+
+	f, err := os.Open(filepath.Join(dir, "short"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	//the segment is read into the encrypted message by the writer
+	sc, cc := startSegmentPair(t, "test_segment_read_error", true, nil)
+	defer sc.Close()
+	defer cc.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	//the segment extends past the end of the file so the writer fails to read it
+	msg := &Message{MsgType: 5, segment: &fileSegment{file: f, offset: 50, length: 100}, written: make(chan error, 1)}
+	_, err = cc.request(ctx, msg)
+	if err == nil || errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("the request should fail with the error of the writer, got: %v", err)
+	}
+
+	//the connection is still usable
+	if err = cc.Write(6, []byte("next")); err != nil {
+		t.Fatal(err)
+	}
+	if m, err := sc.Read(); err != nil || string(m.Data) != "next" {
+		t.Errorf("expected the next message but got: %v %v", m, err)
+	}
+}
+
+func benchmarkFileSegments(b *testing.B, name string, encryption bool, transport Transport) {
+
+	dir := b.TempDir()
+	writeTestFile(b, dir, "bench", fileChunkSize)
+
+	f, err := os.Open(filepath.Join(dir, "bench"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer f.Close()
+
+	sc, cc := startSegmentPair(b, name, encryption, transport)
+	defer sc.Close()
+	defer cc.Close()
+
+	received := make(chan struct{})
+	go func() {
+		for i := 0; i < b.N; i++ {
+			if _, err := sc.Read(); err != nil {
+				return
+			}
+		}
+		close(received)
+	}()
+
+	b.SetBytes(fileChunkSize)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		msg := &Message{MsgType: 5, segment: &fileSegment{file: f, length: fileChunkSize}}
+		if err := cc.writeMessageContext(context.Background(), msg); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	<-received
+}
+
+// BenchmarkFileTransferSendfile - file chunks written with sendfile (linux, unix socket, no encryption)
+func BenchmarkFileTransferSendfile(b *testing.B) {
+	benchmarkFileSegments(b, "bench_sendfile", false, nil)
+}
+
+// BenchmarkFileTransferCopy - file chunks copied through user space (no encryption)
+func BenchmarkFileTransferCopy(b *testing.B) {
+	benchmarkFileSegments(b, "bench_copy", false, copyTransport())
+}
+
+// BenchmarkFileTransferEncrypted - file chunks read into the message and encrypted
+func BenchmarkFileTransferEncrypted(b *testing.B) {
+	benchmarkFileSegments(b, "bench_encrypted", true, nil)
+}
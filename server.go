@@ -59,6 +59,19 @@ func (s *Server) run(clientId int) (*Server, error) {
 	return s, nil
 }
 
+// listen - creates the listener using the configured Transport
+func (s *Server) listen(clientId int) error {
+
+	listener, err := s.getTransport().Listen(s.config.ServerConfig.Name, clientId)
+	if err != nil {
+		return err
+	}
+
+	s.listener = listener
+
+	return nil
+}
+
 func (s *Server) acceptLoop() {
 
 	for {
@@ -73,9 +86,21 @@ func (s *Server) acceptLoop() {
 
 		if status == Listening || status == Disconnected {
 
+			if err := s.authorize(conn); err != nil {
+				s.logger.Warnf("Server.acceptLoop rejected the client: %s", err)
+				s.reject(conn)
+				continue
+			}
+
 			s.setConn(conn)
 			err2 := s.handshake()
 			if err2 != nil {
+				s.setPeerCredentials(nil)
+			}
+			if err2 == errHandshakeAborted {
+				s.logger.Debugf("Server.acceptLoop handshake err: %s", err2)
+				conn.Close()
+			} else if err2 != nil {
 				s.logger.Errorf("Server.acceptLoop handshake err: %s", err2)
 				s.dispatchError(err2)
 				s.setStatus(Error)
@@ -83,19 +108,23 @@ func (s *Server) acceptLoop() {
 				conn.Close()
 
 			} else {
-				go s.read(s.ByteReader)
-				go s.write()
+				s.serveConn(conn, s.ByteReader)
 
 				s.dispatchStatus(Connected)
 			}
+		} else {
+			//only one connection is served at a time, the client would otherwise wait for the handshake indefinitely
+			s.logger.Debugf("Server.acceptLoop closing a connection received while %s", status)
+			conn.Close()
 		}
 	}
 }
 
 func (s *Server) ByteReader(a *Actor, buff []byte) bool {
 
-	_, err := io.ReadFull(a.conn, buff)
+	_, err := io.ReadFull(a.connReader(a.conn), buff)
 	if err != nil {
+		s.setPeerCredentials(nil)
 
 		if a.getStatus() == Closing {
 			a.dispatchStatusBlocking(Closed)
@@ -103,10 +132,10 @@ func (s *Server) ByteReader(a *Actor, buff []byte) bool {
 			return false
 		}
 
-		if err == io.EOF {
-			a.dispatchStatus(Disconnected)
-			return false
-		}
+		// EOF or any other read error (i.e. a reset connection) ends the connection instead of being read again
+		a.logger.Debugf("%s.ByteReader err: %s", a, err)
+		a.dispatchStatus(Disconnected)
+		return false
 	}
 
 	return true
@@ -4,13 +4,17 @@ import (
 	"bytes"
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/sirupsen/logrus"
 )
 
+const maxFileNameLen = 255 // NAME_MAX of most filesystems
+
 // checks the name passed into the start function to ensure it's ok/will work.
 func checkIpcName(ipcName string) error {
 
@@ -18,6 +22,15 @@ func checkIpcName(ipcName string) error {
 		return errors.New("ipcName cannot be an empty string")
 	}
 
+	//the name is a file name and must not escape the socket directory
+	if strings.ContainsAny(ipcName, "/\\\x00") || ipcName == "." || ipcName == ".." {
+		return fmt.Errorf("ipcName %q cannot contain path separators", ipcName)
+	}
+
+	if len(ipcName)+len(SOCKET_NAME_EXT) > maxFileNameLen {
+		return fmt.Errorf("ipcName cannot exceed %d bytes", maxFileNameLen-len(SOCKET_NAME_EXT))
+	}
+
 	return nil
 }
 
@@ -0,0 +1,133 @@
+//go:build !windows && !network
+
+package gipc
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUnixSocketDir(t *testing.T) {
+
+	Sleep()
+
+	dir := t.TempDir()
+	scon := NewServerConfig("test_socket_dir")
+	scon.SocketDir = dir
+	sc, err := StartServer(scon)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sc.Close()
+
+	want := filepath.Join(dir, "test_socket_dir.sock")
+	if got := sc.GetListener().Addr().String(); got != want {
+		t.Errorf("Got %q, Wanted %q", got, want)
+	}
+
+	Sleep()
+
+	ccon := NewClientConfig("test_socket_dir")
+	ccon.SocketDir = dir
+	cc, err := StartClient(ccon)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+
+	waitForConnected(t, &sc.Actor)
+	waitForConnected(t, &cc.Actor)
+}
+
+func TestUnixSocketRuntimeDir(t *testing.T) {
+
+	Sleep()
+
+	dir := t.TempDir()
+	t.Setenv("XDG_RUNTIME_DIR", dir)
+
+	sc, err := StartServer(NewServerConfig("test_socket_runtime_dir"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sc.Close()
+
+	want := filepath.Join(dir, "test_socket_runtime_dir.sock")
+	if got := sc.GetListener().Addr().String(); got != want {
+		t.Errorf("Got %q, Wanted %q", got, want)
+	}
+}
+
+func TestUnixSocketPathMultiClient(t *testing.T) {
+
+	Sleep()
+
+	path := filepath.Join(t.TempDir(), "ctl.sock")
+	scon := NewServerConfig("test_socket_path")
+	scon.SocketPath = path
+	scon.MultiClient = true
+	sc, err := StartServer(scon)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sc.Close()
+
+	mux := NewServeMux()
+	mux.HandleFunc(5, func(ctx context.Context, a *Actor, msg *Message) {
+		a.Reply(msg, msg.Data)
+	})
+	go sc.Serve(mux)
+
+	Sleep()
+
+	for _, name := range []string{"first", "second"} {
+		ccon := NewClientConfig("test_socket_path")
+		ccon.SocketPath = path
+		ccon.MultiClient = true
+		cc, err := StartClient(ccon)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer cc.Close()
+		drainClient(cc)
+
+		if reply, err := cc.Request(context.Background(), 5, []byte(name)); err != nil || string(reply.Data) != name {
+			t.Fatalf("Got %v %v, Wanted %q", reply, err, name)
+		}
+	}
+
+	//the pool sockets are created next to the configured path
+	dir := filepath.Dir(path)
+	for _, name := range []string{"ctl_manager.sock", "ctl1.sock", "ctl2.sock"} {
+		if _, err = os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestUnixSocketNameValidation(t *testing.T) {
+
+	for _, name := range []string{"../escape", "dir/name", "..", "nul\x00name"} {
+		if _, err := StartServer(NewServerConfig(name)); err == nil || !strings.Contains(err.Error(), "path separators") {
+			t.Errorf("expected the name %q to be rejected but got: %v", name, err)
+		}
+	}
+
+	if _, err := StartServer(NewServerConfig(strings.Repeat("n", 300))); err == nil {
+		t.Error("expected the name exceeding the file name limit to be rejected")
+	}
+
+	scon := NewServerConfig(strings.Repeat("n", 120))
+	if _, err := StartServer(scon); err == nil || !strings.Contains(err.Error(), "exceeds") {
+		t.Errorf("expected the socket path to be rejected but got: %v", err)
+	}
+
+	scon = NewServerConfig("test_socket_relative")
+	scon.SocketPath = "relative.sock"
+	if _, err := StartServer(scon); err == nil || !strings.Contains(err.Error(), "not absolute") {
+		t.Errorf("expected the relative socket path to be rejected but got: %v", err)
+	}
+}
@@ -0,0 +1,49 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package gipc
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// lockSocket - holds an exclusive flock on the lock file next to the socket for the lifetime of the
+// listener. A lock file unlinked by a closing server while we waited for it is retried, otherwise two
+// servers could each hold a lock on a different file of the same name.
+func lockSocket(socketName string) (*os.File, error) {
+
+	lockName := socketName + ".lock"
+
+	for {
+		f, err := os.OpenFile(lockName, os.O_RDWR|os.O_CREATE, 0600)
+		if err != nil {
+			return nil, err
+		}
+
+		if err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+			f.Close()
+			if errors.Is(err, syscall.EWOULDBLOCK) {
+				return nil, ErrAddressInUse
+			}
+			return nil, err
+		}
+
+		fi, err := f.Stat()
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		if li, err := os.Stat(lockName); err == nil && os.SameFile(fi, li) {
+			return f, nil
+		}
+
+		f.Close()
+	}
+}
+
+// unlockSocket - the lock file is removed before the lock is released, see lockSocket
+func unlockSocket(f *os.File) {
+	os.Remove(f.Name())
+	f.Close()
+}
@@ -0,0 +1,14 @@
+//go:build !windows && !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package gipc
+
+import (
+	"os"
+)
+
+// lockSocket - flock is unavailable, the listener only relies on probing the existing socket
+func lockSocket(socketName string) (*os.File, error) {
+	return nil, nil
+}
+
+func unlockSocket(f *os.File) {}
@@ -0,0 +1,409 @@
+package gipc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+)
+
+// ErrStreamReset - returned by a stream reset by the remote side (i.e. when its accept backlog is full)
+var ErrStreamReset = errors.New("gipc: the stream was reset")
+
+// the operations of stream frames, carried in the message type of frames flagged with flagStream
+const (
+	streamSYN    = iota + 1 // opens the stream
+	streamData              // carries stream data, never more than the remote window
+	streamWindow            // the 4 byte data is the number of bytes the sender may send in addition
+	streamFIN               // the sender won't write to the stream anymore
+	streamRST               // the stream is aborted in both directions
+)
+
+// streamSYNMessage - the flag in the first byte of the SYN data of a stream opened by WriteStream,
+// the 4 byte message type of the message follows it
+const streamSYNMessage byte = 1
+
+const (
+	streamInitialWindow = 256 * 1024 // the number of bytes which can be sent before the receiver reads them
+	streamAcceptBacklog = 64         // the number of streams waiting for AcceptStream before new streams are reset
+)
+
+// streamSession - the logical streams multiplexed over the connection of an Actor
+type streamSession struct {
+	mutex   sync.Mutex
+	streams map[uint32]*Stream
+	nextId  uint32
+	accept  chan *Stream
+}
+
+// Stream - a logical stream with its own ordering and flow control, multiplexed with the other
+// streams and messages over the connection of an Actor. Stream implements net.Conn. readErr is
+// io.EOF once the remote side closed its write side.
+type Stream struct {
+	*pipe
+	actor *Actor
+	id    uint32
+
+	writeErr    error // set when the stream is reset or the connection is lost, guarded by the mutex of the pipe
+	writeClosed bool
+}
+
+func newStreamSession(isServer bool) *streamSession {
+
+	//the client opens odd stream ids and the server even ones so both can open streams
+	nextId := uint32(1)
+	if isServer {
+		nextId = 2
+	}
+
+	return &streamSession{
+		streams: make(map[uint32]*Stream),
+		nextId:  nextId,
+		accept:  make(chan *Stream, streamAcceptBacklog),
+	}
+}
+
+func newStream(a *Actor, id uint32) *Stream {
+	return &Stream{pipe: newPipe(), actor: a, id: id}
+}
+
+// OpenStream - opens a new logical stream, the remote side receives it from AcceptStream
+func (a *Actor) OpenStream() (*Stream, error) {
+	return a.OpenStreamContext(context.Background())
+}
+
+// OpenStreamContext - opens a new logical stream, abandoning it when ctx is cancelled while
+// waiting for the connection
+func (a *Actor) OpenStreamContext(ctx context.Context) (*Stream, error) {
+	return a.openStream(ctx, []byte{0})
+}
+
+// openStream - sends the SYN of a new stream, syn is its flags and the message type of WriteStream streams
+func (a *Actor) openStream(ctx context.Context, syn []byte) (*Stream, error) {
+
+	ss := a.streams
+
+	ss.mutex.Lock()
+	id := ss.nextId
+	ss.nextId += 2
+	st := newStream(a, id)
+	ss.streams[id] = st
+	ss.mutex.Unlock()
+
+	if err := st.writeFrame(ctx, streamSYN, syn); err != nil {
+		ss.remove(id)
+		return nil, err
+	}
+
+	return st, nil
+}
+
+// AcceptStream - blocks until the remote side opens a stream
+func (a *Actor) AcceptStream() (*Stream, error) {
+	return a.AcceptStreamContext(context.Background())
+}
+
+// AcceptStreamContext - blocks until the remote side opens a stream, returning ctx.Err() when ctx
+// is cancelled or its deadline is exceeded first
+func (a *Actor) AcceptStreamContext(ctx context.Context) (*Stream, error) {
+
+	select {
+	case st := <-a.streams.accept:
+		return st, nil
+	case <-a.closed:
+		return nil, net.ErrClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (ss *streamSession) get(id uint32) *Stream {
+	ss.mutex.Lock()
+	st := ss.streams[id]
+	ss.mutex.Unlock()
+	return st
+}
+
+func (ss *streamSession) remove(id uint32) {
+	ss.mutex.Lock()
+	delete(ss.streams, id)
+	ss.mutex.Unlock()
+}
+
+// handle - called from the read loop with each stream frame, must not block. Returns the message
+// to deliver to the received channel when the stream was opened by WriteStream
+func (ss *streamSession) handle(a *Actor, msg *Message) *Message {
+
+	if msg.MsgType == streamSYN {
+		st := newStream(a, msg.id)
+
+		ss.mutex.Lock()
+		_, exists := ss.streams[msg.id]
+		if !exists {
+			ss.streams[msg.id] = st
+		}
+		ss.mutex.Unlock()
+
+		if exists {
+			a.logger.Errorf("%s.read - stream %d is already open", a, msg.id)
+			return nil
+		}
+
+		//the SYN of a stream opened by WriteStream carries the message type
+		if len(msg.Data) == 5 && msg.Data[0]&streamSYNMessage != 0 {
+			//the receiving side of a message is read only
+			st.writeClosed = true
+			return &Message{MsgType: bytesToInt(msg.Data[1:]), Reader: st}
+		}
+
+		select {
+		case ss.accept <- st:
+		default:
+			a.logger.Warnf("%s.read - resetting stream %d, the accept backlog is full", a, msg.id)
+			ss.remove(msg.id)
+			go st.writeFrame(context.Background(), streamRST, nil)
+		}
+		return nil
+	}
+
+	st := ss.get(msg.id)
+	if st == nil {
+		if msg.MsgType == streamData {
+			//the stream was closed locally, tell the remote side to stop writing
+			go newStream(a, msg.id).writeFrame(context.Background(), streamRST, nil)
+		}
+		return nil
+	}
+
+	switch msg.MsgType {
+	case streamData:
+		if !st.receive(msg.Data) {
+			a.logger.Errorf("%s.read - resetting stream %d, the remote side exceeded the window", a, msg.id)
+			st.fail(ErrStreamReset)
+			go st.writeFrame(context.Background(), streamRST, nil)
+		}
+	case streamWindow:
+		if len(msg.Data) != 4 {
+			return nil
+		}
+		st.grant(bytesToInt(msg.Data))
+	case streamFIN:
+		st.mutex.Lock()
+		if st.readErr == nil {
+			st.readErr = io.EOF
+		}
+		done := st.writeClosed
+		st.mutex.Unlock()
+
+		if done {
+			ss.remove(st.id)
+		}
+		signal(st.readable)
+	case streamRST:
+		st.fail(ErrStreamReset)
+	}
+
+	return nil
+}
+
+// connectionLost - streams don't survive a reconnect, they all fail with ErrConnectionLost
+func (ss *streamSession) connectionLost() {
+
+	ss.mutex.Lock()
+	streams := ss.streams
+	ss.streams = make(map[uint32]*Stream)
+	ss.mutex.Unlock()
+
+	for _, st := range streams {
+		st.fail(ErrConnectionLost)
+	}
+
+	for {
+		select {
+		case <-ss.accept:
+		default:
+			return
+		}
+	}
+}
+
+// fail - aborts both directions of the stream with err
+func (st *Stream) fail(err error) {
+	st.mutex.Lock()
+	if st.readErr == nil || st.readErr == io.EOF {
+		st.readErr = err
+	}
+	if st.writeErr == nil {
+		st.writeErr = err
+	}
+	st.mutex.Unlock()
+
+	st.actor.streams.remove(st.id)
+	st.wake()
+}
+
+// reset - aborts the stream on both sides
+func (st *Stream) reset(err error) {
+	st.fail(err)
+	st.writeFrame(context.Background(), streamRST, nil)
+}
+
+func (st *Stream) writeFrame(ctx context.Context, op int, data []byte) error {
+	//stream frames bypass the interceptors, their message type is the operation
+	return writeMessage(ctx, st.actor, &Message{MsgType: op, Data: data, id: st.id, flags: flagStream})
+}
+
+// ID - the id of the stream, odd for streams opened by the client and even for the server
+func (st *Stream) ID() uint32 {
+	return st.id
+}
+
+func (st *Stream) Read(b []byte) (int, error) {
+
+	n, update, err := st.read(b)
+	if update > 0 {
+		st.writeFrame(context.Background(), streamWindow, intToBytes(update))
+	}
+
+	return n, err
+}
+
+// Write - blocks while the remote window is exhausted, so a slow reader only holds up its own stream
+func (st *Stream) Write(b []byte) (int, error) {
+
+	check := func() error {
+		if st.closed || st.writeClosed {
+			return net.ErrClosed
+		}
+		return st.writeErr
+	}
+
+	return st.write(b, st.actor.maxMsgSize(), check, func(ctx context.Context, chunk []byte) error {
+		return st.writeFrame(ctx, streamData, chunk)
+	})
+}
+
+// CloseWrite - half-closes the stream, the remote side reads io.EOF once it has read the data
+// while this side can still read
+func (st *Stream) CloseWrite() error {
+
+	st.mutex.Lock()
+	if st.closed || st.writeClosed {
+		st.mutex.Unlock()
+		return net.ErrClosed
+	}
+	st.writeClosed = true
+	notify := st.writeErr == nil
+	done := st.readErr != nil
+	st.mutex.Unlock()
+
+	if done {
+		st.actor.streams.remove(st.id)
+	}
+	signal(st.writable)
+
+	if notify {
+		return st.writeFrame(context.Background(), streamFIN, nil)
+	}
+
+	return nil
+}
+
+// Close - closes both directions of the stream, data the remote side writes afterwards resets its stream
+func (st *Stream) Close() error {
+
+	st.mutex.Lock()
+	if st.closed {
+		st.mutex.Unlock()
+		return net.ErrClosed
+	}
+	st.closed = true
+	notify := !st.writeClosed && st.writeErr == nil
+	st.writeClosed = true
+	st.mutex.Unlock()
+
+	st.actor.streams.remove(st.id)
+	st.wake()
+
+	if notify {
+		return st.writeFrame(context.Background(), streamFIN, nil)
+	}
+
+	return nil
+}
+
+func (st *Stream) LocalAddr() net.Addr {
+	return streamAddr{name: st.actor.name(), id: st.id}
+}
+
+func (st *Stream) RemoteAddr() net.Addr {
+	return streamAddr{name: st.actor.name(), id: st.id}
+}
+
+type streamAddr struct {
+	name string
+	id   uint32
+}
+
+func (addr streamAddr) Network() string {
+	return "gipc"
+}
+
+func (addr streamAddr) String() string {
+	return fmt.Sprintf("%s#%d", addr.name, addr.id)
+}
+
+// WriteStream - writes the content of r as a single message of msgType without buffering it, so
+// payloads larger than the maximum message size can be sent. The message is received with its
+// Reader set instead of Data, WriteStream returns once r is read and written to the connection.
+func (a *Actor) WriteStream(msgType int, r io.Reader) error {
+	return a.WriteStreamContext(context.Background(), msgType, r)
+}
+
+// WriteStreamContext - WriteStream which aborts the message when ctx is cancelled, the receiver
+// then reads ErrStreamReset
+func (a *Actor) WriteStreamContext(ctx context.Context, msgType int, r io.Reader) error {
+
+	if msgType <= 0 {
+		err := fmt.Errorf("cannot write a stream of message type %d, message types must be greater than 0", msgType)
+		a.logger.Errorf("%s.WriteStream err: %s", a, err)
+		return err
+	}
+
+	st, err := a.openStream(ctx, append([]byte{streamSYNMessage}, intToBytes(msgType)...))
+	if err != nil {
+		return err
+	}
+
+	stop := context.AfterFunc(ctx, func() {
+		st.reset(ctx.Err())
+	})
+	defer stop()
+
+	buff := make([]byte, min(a.maxMsgSize(), streamInitialWindow))
+	for {
+		n, readErr := r.Read(buff)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if n > 0 {
+			if _, err = st.Write(buff[:n]); err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				st.reset(ErrStreamReset)
+				return err
+			}
+		}
+		if readErr == io.EOF {
+			break
+		} else if readErr != nil {
+			st.reset(ErrStreamReset)
+			return readErr
+		}
+	}
+
+	return st.Close()
+}
@@ -0,0 +1,266 @@
+package gipc
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestStreamMultiplex(t *testing.T) {
+
+	Sleep()
+
+	sc, cc := startConnPair(t, "test_stream")
+	defer sc.Close()
+	defer cc.Close()
+
+	//the server echoes every stream it accepts until the client closes its write side
+	go func() {
+		for {
+			st, err := sc.AcceptStream()
+			if err != nil {
+				return
+			}
+			go func() {
+				io.Copy(st, st)
+				st.Close()
+			}()
+		}
+	}()
+
+	bulk, err := cc.OpenStream()
+	if err != nil {
+		t.Fatal(err)
+	}
+	small, err := cc.OpenStream()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bulk.ID()%2 != 1 || small.ID() != bulk.ID()+2 {
+		t.Errorf("unexpected client stream ids %d and %d", bulk.ID(), small.ID())
+	}
+
+	//several times the window, the bulk stream blocks until its echo is read
+	large := bytes.Repeat([]byte("0123456789"), streamInitialWindow)
+	bulkDone := make(chan error, 1)
+	go func() {
+		_, err := bulk.Write(large)
+		bulkDone <- err
+	}()
+
+	//the small stream completes while the bulk stream is still being written
+	small.Write([]byte("ping"))
+	small.CloseWrite()
+	got, err := io.ReadAll(small)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "ping" {
+		t.Errorf("Got %q, Wanted %q", got, "ping")
+	}
+
+	select {
+	case err = <-bulkDone:
+		t.Fatalf("the bulk write should be blocked by the window until it is read, got: %v", err)
+	default:
+	}
+
+	echoed := make(chan []byte, 1)
+	go func() {
+		data, _ := io.ReadAll(bulk)
+		echoed <- data
+	}()
+
+	if err = <-bulkDone; err != nil {
+		t.Fatal(err)
+	}
+	bulk.CloseWrite()
+
+	if data := <-echoed; !bytes.Equal(data, large) {
+		t.Errorf("Got %d bytes, Wanted %d bytes", len(data), len(large))
+	}
+
+	//plain messages are still delivered alongside the streams
+	cc.Write(5, []byte("message"))
+	m, err := sc.Read()
+	if err != nil || m.MsgType != 5 {
+		t.Errorf("expected message type 5 but got: %v %v", m, err)
+	}
+}
+
+func TestStreamServerOpenAndLost(t *testing.T) {
+
+	Sleep()
+
+	sc, cc := startConnPair(t, "test_stream_lost")
+	defer cc.Close()
+
+	st, err := sc.OpenStream()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.ID()%2 != 0 {
+		t.Errorf("server stream ids should be even, got %d", st.ID())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	cst, err := cc.AcceptStreamContext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cst.ID() != st.ID() {
+		t.Errorf("Got stream %d, Wanted %d", cst.ID(), st.ID())
+	}
+
+	readErr := make(chan error, 1)
+	go func() {
+		_, err := cst.Read(make([]byte, 1))
+		readErr <- err
+	}()
+
+	sc.Close()
+
+	if err = <-readErr; err != ErrConnectionLost {
+		t.Errorf("expected ErrConnectionLost but got: %v", err)
+	}
+	if _, err = st.Write([]byte("x")); err != ErrConnectionLost {
+		t.Errorf("expected ErrConnectionLost but got: %v", err)
+	}
+}
+
+// patternReader - an endless reader of a repeating pattern
+type patternReader struct {
+	offset int
+}
+
+func (pr *patternReader) Read(b []byte) (int, error) {
+	for i := range b {
+		b[i] = byte(pr.offset % 251)
+		pr.offset++
+	}
+	return len(b), nil
+}
+
+func TestStreamWriteStream(t *testing.T) {
+
+	Sleep()
+
+	sc, cc := startConnPair(t, "test_write_stream")
+	defer sc.Close()
+	defer cc.Close()
+
+	//several times the maximum message size
+	size := int64(MAX_MSG_SIZE*3 + 17)
+
+	written := make(chan error, 1)
+	go func() {
+		written <- cc.WriteStream(7, io.LimitReader(&patternReader{}, size))
+	}()
+
+	m, err := sc.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.MsgType != 7 || m.Reader == nil || m.Data != nil {
+		t.Fatalf("expected a stream message of type 7 but got: %+v", m)
+	}
+
+	//other messages are read while the stream message is still being received
+	cc.Write(8, []byte("small"))
+	small, err := sc.Read()
+	if err != nil || small.MsgType != 8 {
+		t.Errorf("expected message type 8 but got: %v %v", small, err)
+	}
+
+	hashes := make(chan [2][]byte, 1)
+	go func() {
+		want := sha256.New()
+		io.Copy(want, io.LimitReader(&patternReader{}, size))
+		got := sha256.New()
+		n, _ := io.Copy(got, m.Reader)
+		if n != size {
+			t.Errorf("Got %d bytes, Wanted %d bytes", n, size)
+		}
+		hashes <- [2][]byte{got.Sum(nil), want.Sum(nil)}
+	}()
+
+	if err = <-written; err != nil {
+		t.Fatal(err)
+	}
+	if h := <-hashes; !bytes.Equal(h[0], h[1]) {
+		t.Error("the received payload doesn't match the written payload")
+	}
+	m.Reader.Close()
+
+	if err = cc.WriteStream(0, bytes.NewReader(nil)); err == nil {
+		t.Error("message type 0 should be rejected")
+	}
+
+	//cancelling the write aborts the message on the receiving side
+	ctx, cancel := context.WithCancel(context.Background())
+	pr, pw := io.Pipe()
+	go func() {
+		written <- cc.WriteStreamContext(ctx, 9, pr)
+	}()
+	pw.Write([]byte("partial"))
+
+	m, err = sc.Read()
+	if err != nil || m.MsgType != 9 {
+		t.Fatalf("expected message type 9 but got: %v %v", m, err)
+	}
+	cancel()
+	go func() {
+		pw.Write([]byte("after the cancel"))
+		pw.Close()
+	}()
+
+	if err = <-written; err != context.Canceled {
+		t.Errorf("expected context.Canceled but got: %v", err)
+	}
+	if _, err = io.ReadAll(m.Reader); err != ErrStreamReset {
+		t.Errorf("expected ErrStreamReset but got: %v", err)
+	}
+}
+
+func TestStreamSYNFlag(t *testing.T) {
+
+	Sleep()
+
+	sc, cc := startConnPair(t, "test_stream_syn")
+	defer sc.Close()
+	defer cc.Close()
+
+	//SYN data of the length of a WriteStream SYN without its flag still opens a plain stream
+	st := newStream(&cc.Actor, 101)
+	if err := st.writeFrame(context.Background(), streamSYN, []byte{0, 0, 0, 0, 30}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	accepted, err := sc.AcceptStreamContext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if accepted.ID() != 101 {
+		t.Errorf("Got stream %d, Wanted 101", accepted.ID())
+	}
+
+	//the flagged SYN of WriteStream is received as a message
+	if err = cc.WriteStream(30, bytes.NewReader([]byte("message"))); err != nil {
+		t.Fatal(err)
+	}
+	m, err := sc.Read()
+	if err != nil || m.MsgType != 30 || m.Reader == nil {
+		t.Fatalf("expected a streamed message of type 30 but got: %v %v", m, err)
+	}
+	if data, err := io.ReadAll(m.Reader); err != nil || string(data) != "message" {
+		t.Errorf("Got %q %v, Wanted %q", data, err, "message")
+	}
+}
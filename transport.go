@@ -0,0 +1,169 @@
+package gipc
+
+import (
+	"context"
+	"errors"
+	"net"
+)
+
+// ErrAddressInUse - returned by StartServer when a live server already owns the name
+var ErrAddressInUse = errors.New("gipc: the address is in use by another server")
+
+// Transport - creates the listeners and connections used by a Server and Client.
+// The default transport is chosen at build time (unix socket, named pipe or tcp with
+// the network build tag) but can be overridden per ServerConfig/ClientConfig.
+type Transport interface {
+	// Listen - creates the listener for the server identified by name and clientId
+	Listen(name string, clientId int) (net.Listener, error)
+	// Dial - connects to the server identified by name and clientId
+	Dial(ctx context.Context, name string, clientId int) (net.Conn, error)
+}
+
+// configurableTransport - implemented by the built-in transports which inherit
+// options (i.e. UnmaskPermissions) from the ServerConfig or ClientConfig
+type configurableTransport interface {
+	withConfig(ac *ActorConfig) Transport
+}
+
+// ListenerTransport - serves a pre-opened listener (i.e. systemd socket activation or a test harness).
+// Listener is returned for clientId 0, all other listeners and dials are delegated to Transport.
+type ListenerTransport struct {
+	Listener  net.Listener
+	Transport Transport // the transport used for dialing and pool clients (default transport when nil)
+}
+
+func (t *ListenerTransport) fallback() Transport {
+	if t.Transport != nil {
+		return t.Transport
+	}
+	return defaultTransport()
+}
+
+func (t *ListenerTransport) withConfig(ac *ActorConfig) Transport {
+	tc := *t
+	if ct, ok := tc.fallback().(configurableTransport); ok {
+		tc.Transport = ct.withConfig(ac)
+	}
+	return &tc
+}
+
+func (t *ListenerTransport) Listen(name string, clientId int) (net.Listener, error) {
+	if clientId == 0 {
+		if t.Listener == nil {
+			return nil, errors.New("ListenerTransport requires a Listener")
+		}
+		return t.Listener, nil
+	}
+	return t.fallback().Listen(name, clientId)
+}
+
+func (t *ListenerTransport) Dial(ctx context.Context, name string, clientId int) (net.Conn, error) {
+	return t.fallback().Dial(ctx, name, clientId)
+}
+
+// WrapTransport - returns a Transport which passes every accepted and dialed connection
+// through wrap, allowing metrics, logging or fault injection around the raw connection.
+func WrapTransport(t Transport, wrap func(net.Conn) net.Conn) Transport {
+	return &wrappedTransport{transport: t, wrap: wrap}
+}
+
+type wrappedTransport struct {
+	transport Transport
+	wrap      func(net.Conn) net.Conn
+}
+
+type wrappedListener struct {
+	net.Listener
+	wrap func(net.Conn) net.Conn
+}
+
+func (t *wrappedTransport) inner() Transport {
+	if t.transport != nil {
+		return t.transport
+	}
+	return defaultTransport()
+}
+
+func (t *wrappedTransport) withConfig(ac *ActorConfig) Transport {
+	tc := *t
+	if ct, ok := tc.inner().(configurableTransport); ok {
+		tc.transport = ct.withConfig(ac)
+	}
+	return &tc
+}
+
+func (t *wrappedTransport) Listen(name string, clientId int) (net.Listener, error) {
+	listener, err := t.inner().Listen(name, clientId)
+	if err != nil {
+		return nil, err
+	}
+	return &wrappedListener{Listener: listener, wrap: t.wrap}, nil
+}
+
+func (t *wrappedTransport) Dial(ctx context.Context, name string, clientId int) (net.Conn, error) {
+	conn, err := t.inner().Dial(ctx, name, clientId)
+	if err != nil {
+		return nil, err
+	}
+	return t.wrap(conn), nil
+}
+
+func (l *wrappedListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return l.wrap(conn), nil
+}
+
+// socketLocation - the SocketDir and SocketPath of the ServerConfig or ClientConfig
+func (ac *ActorConfig) socketLocation() (string, string) {
+	if ac.IsServer {
+		return ac.ServerConfig.SocketDir, ac.ServerConfig.SocketPath
+	}
+	return ac.ClientConfig.SocketDir, ac.ClientConfig.SocketPath
+}
+
+func (a *Actor) getTransport() Transport {
+
+	var t Transport
+	if a.config.IsServer {
+		t = a.config.ServerConfig.Transport
+	} else {
+		t = a.config.ClientConfig.Transport
+	}
+
+	if t == nil {
+		t = defaultTransport()
+	}
+
+	if ct, ok := t.(configurableTransport); ok {
+		t = ct.withConfig(a.config)
+	}
+
+	return t
+}
+
+// isAddrInUse - the listen error of an address another server is listening on, see addrInUseErrors
+func isAddrInUse(err error) bool {
+	for _, target := range addrInUseErrors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// isDialRetryable - errors which happen a lot when the server has not started listening yet
+// or the connection closes under normal circumstances, see dialRetryableErrors
+func isDialRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	for _, target := range dialRetryableErrors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,250 @@
+package gipc
+
+import (
+	"errors"
+	"net"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func transportPingPong(t *testing.T, sc *Server, cc *Client) {
+
+	complete := make(chan bool, 1)
+
+	go func() {
+		for {
+			m, err := sc.Read()
+			if err != nil {
+				continue
+			}
+			if m.MsgType == 5 {
+				sc.Write(6, m.Data)
+				return
+			}
+		}
+	}()
+
+	go func() {
+		for {
+			m, err := cc.Read()
+			if err != nil {
+				t.Error(err)
+				complete <- false
+				return
+			}
+			if m.Status == "Connected" {
+				cc.Write(5, []byte("ping"))
+			} else if m.MsgType == 6 {
+				if string(m.Data) != "ping" {
+					t.Errorf("Got %q, Wanted %q", m.Data, "ping")
+				}
+				complete <- true
+				return
+			}
+		}
+	}()
+
+	<-complete
+}
+
+func TestTransportDefaultAndNetwork(t *testing.T) {
+
+	Sleep()
+
+	//the same process listening on the default transport and tcp at the same time
+	sc, err := StartServer(NewServerConfig("test_transport"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sc.Close()
+
+	nscon := NewServerConfig("test_transport")
+	nscon.Transport = &NetworkTransport{Port: 7350}
+	nsc, err := StartServer(nscon)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer nsc.Close()
+
+	Sleep()
+
+	cc, err := StartClient(NewClientConfig("test_transport"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+
+	nccon := NewClientConfig("test_transport")
+	nccon.Transport = &NetworkTransport{Port: 7350}
+	ncc, err := StartClient(nccon)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ncc.Close()
+
+	transportPingPong(t, sc, cc)
+	transportPingPong(t, nsc, ncc)
+
+	if nsc.GetListener().Addr().String() != "127.0.0.1:7350" {
+		t.Errorf("Got %q, Wanted %q", nsc.GetListener().Addr().String(), "127.0.0.1:7350")
+	}
+}
+
+func TestTransportListenerAndWrap(t *testing.T) {
+
+	Sleep()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	var accepted, dialed int32
+	scon := NewServerConfig("test_transport_listener")
+	scon.Transport = WrapTransport(&ListenerTransport{Listener: listener}, func(conn net.Conn) net.Conn {
+		atomic.AddInt32(&accepted, 1)
+		return conn
+	})
+	sc, err := StartServer(scon)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sc.Close()
+
+	if sc.GetListener().Addr().String() != listener.Addr().String() {
+		t.Errorf("Got %q, Wanted %q", sc.GetListener().Addr().String(), listener.Addr().String())
+	}
+
+	Sleep()
+
+	ccon := NewClientConfig("test_transport_listener")
+	ccon.Transport = WrapTransport(&NetworkTransport{Port: port}, func(conn net.Conn) net.Conn {
+		atomic.AddInt32(&dialed, 1)
+		return conn
+	})
+	cc, err := StartClient(ccon)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+
+	transportPingPong(t, sc, cc)
+
+	if atomic.LoadInt32(&accepted) != 1 || atomic.LoadInt32(&dialed) != 1 {
+		t.Errorf("expected one wrapped connection on each side, got accepted=%d dialed=%d", accepted, dialed)
+	}
+}
+
+func TestTransportFatalDialError(t *testing.T) {
+
+	Sleep()
+
+	ccon := NewClientConfig("test_transport_fatal")
+	ccon.Transport = &NetworkTransport{Port: 70000}
+	ccon.Timeout = 5 * time.Second
+
+	start := time.Now()
+	cc, err := StartClient(ccon)
+	if err == nil {
+		t.Fatal("the client should fail to dial an invalid port")
+	}
+	if time.Since(start) >= ccon.Timeout {
+		t.Errorf("the client should stop dialing after a fatal error instead of timing out")
+	}
+
+	//the error is received once after the Connecting status, then the reads end without the status
+	//dispatches still pending on the received channel panicking
+	for err = nil; err == nil; {
+		_, err = cc.Read()
+	}
+	if err == errReceivedChannelClosed {
+		t.Error("the dial error should be received")
+	}
+	if _, err = cc.Read(); err != errReceivedChannelClosed {
+		t.Errorf("Got %v, Wanted %v", err, errReceivedChannelClosed)
+	}
+}
+
+func TestTransportNetworkAddressInUse(t *testing.T) {
+
+	Sleep()
+
+	scon := NewServerConfig("test_transport_in_use")
+	scon.Transport = &NetworkTransport{Port: 7360}
+	sc, err := StartServer(scon)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sc.Close()
+
+	//the listen error of the port is mapped on every platform, i.e. WSAEADDRINUSE on windows
+	sc2, err := StartServer(scon)
+	if !errors.Is(err, ErrAddressInUse) {
+		t.Fatalf("Got %v, Wanted %v", err, ErrAddressInUse)
+	}
+	sc2.Close()
+}
+
+// resetConn - fails the next Read with ECONNRESET once reset is set, as when the process of the
+// remote side is killed
+type resetConn struct {
+	net.Conn
+	reset *atomic.Bool
+}
+
+func (c *resetConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if c.reset.CompareAndSwap(true, false) {
+		c.Conn.Close()
+		return 0, syscall.ECONNRESET
+	}
+	return n, err
+}
+
+func TestTransportClientReadError(t *testing.T) {
+
+	Sleep()
+
+	sc, err := StartServer(NewServerConfig("test_transport_read_error"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sc.Close()
+
+	Sleep()
+
+	reset := &atomic.Bool{}
+	ccon := NewClientConfig("test_transport_read_error")
+	ccon.RetryTimer = 50 * time.Millisecond
+	ccon.Transport = WrapTransport(nil, func(conn net.Conn) net.Conn {
+		return &resetConn{Conn: conn, reset: reset}
+	})
+	cc, err := StartClient(ccon)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+
+	transportPingPong(t, sc, cc)
+
+	//a read error other than io.EOF reconnects the client as well
+	reset.Store(true)
+	if err = sc.Write(7, []byte("reset")); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		transportPingPong(t, sc, cc)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("the client should reconnect after the connection was reset")
+	}
+}
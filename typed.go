@@ -0,0 +1,108 @@
+package gipc
+
+import (
+	"context"
+)
+
+// TypedClient - sends requests of type Req and receives replies of type Resp using the
+// Codec of the Client, so an IPC contract can be shared as Go types instead of a MsgType convention
+type TypedClient[Req, Resp any] struct {
+	Client  *Client
+	MsgType int
+}
+
+// TypedServer - receives requests of type Req and replies with Resp using the Codec of the Server
+type TypedServer[Req, Resp any] struct {
+	Server  *Server
+	MsgType int
+}
+
+// TypedHandlerFunc - handles a typed request, a returned error is passed to the caller as a *RemoteError
+type TypedHandlerFunc[Req, Resp any] func(ctx context.Context, req Req) (Resp, error)
+
+func NewTypedClient[Req, Resp any](c *Client, msgType int) *TypedClient[Req, Resp] {
+	return &TypedClient[Req, Resp]{Client: c, MsgType: msgType}
+}
+
+func NewTypedServer[Req, Resp any](s *Server, msgType int) *TypedServer[Req, Resp] {
+	return &TypedServer[Req, Resp]{Server: s, MsgType: msgType}
+}
+
+// Call - sends req and blocks until the typed reply is received or ctx is done
+func (tc *TypedClient[Req, Resp]) Call(ctx context.Context, req Req) (Resp, error) {
+
+	var resp Resp
+
+	data, err := tc.Client.getCodec().Marshal(req)
+	if err != nil {
+		return resp, err
+	}
+
+	reply, err := tc.Client.Request(ctx, tc.MsgType, data)
+	if err != nil {
+		return resp, err
+	}
+
+	err = tc.Client.getCodec().Unmarshal(reply.Data, &resp)
+
+	return resp, err
+}
+
+// Send - sends req without waiting for a reply
+func (tc *TypedClient[Req, Resp]) Send(ctx context.Context, req Req) error {
+
+	data, err := tc.Client.getCodec().Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	return tc.Client.WriteContext(ctx, tc.MsgType, data)
+}
+
+// Handler - returns a Handler decoding each request, calling fn and replying with its result
+func (ts *TypedServer[Req, Resp]) Handler(fn TypedHandlerFunc[Req, Resp]) Handler {
+
+	return HandlerFunc(func(ctx context.Context, a *Actor, msg *Message) {
+
+		var req Req
+		err := a.getCodec().Unmarshal(msg.Data, &req)
+		if err != nil {
+			a.logger.Errorf("%s.TypedServer decode err: %s", a, err)
+			if msg.IsRequest() {
+				a.ReplyError(msg, err)
+			}
+			return
+		}
+
+		resp, err := fn(ctx, req)
+		if !msg.IsRequest() {
+			//sent without waiting for a reply
+			return
+		}
+
+		if err != nil {
+			a.ReplyError(msg, err)
+			return
+		}
+
+		data, err := a.getCodec().Marshal(resp)
+		if err != nil {
+			a.ReplyError(msg, err)
+			return
+		}
+
+		a.ReplyContext(ctx, msg, data)
+	})
+}
+
+// Handle - registers fn on mux for the MsgType of the TypedServer
+func (ts *TypedServer[Req, Resp]) Handle(mux *ServeMux, fn TypedHandlerFunc[Req, Resp]) {
+	mux.Handle(ts.MsgType, ts.Handler(fn))
+}
+
+// Serve - serves fn until ctx is done or the server is closed
+func (ts *TypedServer[Req, Resp]) Serve(ctx context.Context, fn TypedHandlerFunc[Req, Resp]) error {
+	mux := NewServeMux()
+	ts.Handle(mux, fn)
+	return ts.Server.ServeContext(ctx, mux)
+}
@@ -0,0 +1,70 @@
+package gipc
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type sumRequest struct {
+	Values []int
+}
+
+type sumResponse struct {
+	Total int
+}
+
+func TestCodecTypedClientServer(t *testing.T) {
+
+	Sleep()
+
+	sc, err := StartServer(NewServerConfig("test_typed"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sc.Close()
+
+	ts := NewTypedServer[sumRequest, sumResponse](sc, 5)
+	go ts.Serve(context.Background(), func(ctx context.Context, req sumRequest) (sumResponse, error) {
+		if len(req.Values) == 0 {
+			return sumResponse{}, errors.New("nothing to sum")
+		}
+		total := 0
+		for _, v := range req.Values {
+			total += v
+		}
+		return sumResponse{Total: total}, nil
+	})
+
+	Sleep()
+
+	cc, err := StartClient(NewClientConfig("test_typed"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+
+	go func() {
+		for {
+			if _, err := cc.Read(); err != nil {
+				return
+			}
+		}
+	}()
+
+	tc := NewTypedClient[sumRequest, sumResponse](cc, 5)
+
+	resp, err := tc.Call(context.Background(), sumRequest{Values: []int{1, 2, 3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Total != 6 {
+		t.Errorf("Got %d, Wanted %d", resp.Total, 6)
+	}
+
+	_, err = tc.Call(context.Background(), sumRequest{})
+	var remoteErr *RemoteError
+	if !errors.As(err, &remoteErr) || remoteErr.Message != "nothing to sum" {
+		t.Errorf("expected a RemoteError with the handler error but got: %v", err)
+	}
+}
@@ -2,7 +2,9 @@ package gipc
 
 import (
 	"crypto/cipher"
+	"io"
 	"net"
+	"os"
 	"sync"
 	"time"
 
@@ -19,6 +21,16 @@ type Actor struct {
 	cipher    *cipher.AEAD
 	clientRef *Client
 	mutex     *sync.Mutex
+	pending   *pendingRequests
+	routes    *routeTable
+	streams   *streamSession
+	fragments *fragmentTable
+	fds       *fdQueue
+	hooks     *statusHooks
+	closed    chan struct{} // closed when Close is called
+	closeOnce *sync.Once
+	failed    chan struct{} // closed when a client reads a fatal error, ends its reads and writes
+	failOnce  *sync.Once
 }
 
 // Server - holds the details of the server connection & config.
@@ -26,6 +38,7 @@ type Server struct {
 	Actor
 	listener    net.Listener
 	Connections *ConnectionPool
+	peerCreds   *PeerCredentials // the credentials of the client read when its connection was accepted
 }
 
 // Client - holds the details of the client connection and config.
@@ -42,6 +55,8 @@ type ConnectionPool struct {
 	ServerConfig *ServerConfig
 	Logger       *logrus.Logger
 	mutex        *sync.Mutex
+	onAdd        map[int]func(*Server) // the callbacks of forEachServer keyed by registration
+	nextAdd      int
 }
 
 type ActorConfig struct {
@@ -58,24 +73,63 @@ type ServerConfig struct {
 	LogLevel          string
 	MultiClient       bool
 	Encryption        bool
+	Transport         Transport     // overrides the default transport (unix socket, or tcp with the network build tag)
+	Interceptors      []Interceptor // middleware applied to the messages of every connection
+	Codec             Codec         // marshals the values of WriteValue and ReadValue (default is JSONCodec)
+	Registry          *Registry     // maps message types to Go types (default is DefaultRegistry)
+	// MaxFragmentedMsgSize - messages larger than MaxMsgSize up to this size are split into fragments
+	// when written and reassembled when read (default is 0, fragmentation is disabled)
+	MaxFragmentedMsgSize int
+	// Authorize - called with the peer credentials of each connection before the handshake, returning
+	// an error rejects the client (default is nil, every client is accepted)
+	Authorize AuthorizeFunc
+	// SocketDir - the directory of the unix socket (default is $XDG_RUNTIME_DIR when set, otherwise /tmp/)
+	SocketDir string
+	// SocketPath - the absolute path of the unix socket, overrides SocketDir. The sockets of MultiClient
+	// mode are created next to it.
+	SocketPath string
+	// SocketMode - the permissions of the unix socket file, i.e. 0660 to only allow the SocketGID group
+	// (default is 0, the umask of the process applies, or 0777 with UnmaskPermissions)
+	SocketMode os.FileMode
+	// SocketUID, SocketGID - the owner and group of the unix socket file, 0 is root (default is nil, unchanged)
+	SocketUID *int
+	SocketGID *int
 }
 
 // ClientConfig - used to pass configuration overrides to ClientStart()
 type ClientConfig struct {
-	Name        string
-	Timeout     time.Duration // the duration to wait before abandoning a dial attempt
-	RetryTimer  time.Duration // the duration to wait in dial loop iteration and reconnect attempts
-	LogLevel    string
-	MultiClient bool
-	Encryption  bool
+	Name         string
+	Timeout      time.Duration // the duration to wait before abandoning a dial attempt and, in MultiClient mode, the client id reply
+	RetryTimer   time.Duration // the duration to wait in dial loop iteration and reconnect attempts
+	LogLevel     string
+	MultiClient  bool
+	Encryption   bool
+	Transport    Transport     // overrides the default transport, must match the Transport of the ServerConfig
+	Interceptors []Interceptor // middleware applied to inbound and outbound messages
+	Codec        Codec         // must match the Codec of the ServerConfig (default is JSONCodec)
+	Registry     *Registry     // maps message types to Go types (default is DefaultRegistry)
+	// MaxFragmentedMsgSize - messages larger than the negotiated maximum message size up to this size
+	// are split into fragments when written and reassembled when read (default is 0, disabled)
+	MaxFragmentedMsgSize int
+	// SocketDir - must match the SocketDir of the ServerConfig
+	SocketDir string
+	// SocketPath - must match the SocketPath of the ServerConfig
+	SocketPath string
 }
 
 // Message - contains the received message
 type Message struct {
-	Err     error  // details of any error
-	MsgType int    // 0 = reserved , -1 is an internal message (disconnection or error etc), all messages received will be > 0
-	Data    []byte // message data received
-	Status  string // the status of the connection
+	Err     error         // details of any error
+	MsgType int           // 0 = reserved , -1 is an internal message (disconnection or error etc), all messages received will be > 0
+	Data    []byte        // message data received
+	Status  string        // the status of the connection
+	Reader  io.ReadCloser // set instead of Data for messages written with WriteStream
+	Files   []*os.File    // the file descriptors attached with WriteFDs, the receiver must close them
+	id      uint32        // correlation id shared by a request and its reply
+	segment *fileSegment  // written after Data without being copied into it
+	fds     []*os.File    // the duplicated file descriptors attached by WriteFDs, closed once written
+	written chan error    // receives the result of the writer when set, buffered so the writer never waits
+	flags   byte          // frame header flags
 }
 
 // Status - Status of the connection
@@ -3,13 +3,18 @@ package gipc
 import "github.com/sirupsen/logrus"
 
 const (
-	VERSION                = 2       // ipc package VERSION
+	VERSION                = 3       // ipc package VERSION
 	MAX_MSG_SIZE           = 3145728 // 3Mb  - Maximum bytes allowed for each message
 	DEFAULT_WAIT           = 10
 	DEFAULT_LOG_LEVEL      = logrus.ErrorLevel
 	SOCKET_NAME_BASE       = "/tmp/"
 	SOCKET_NAME_EXT        = ".sock"
 	CLIENT_CONNECT_MSGTYPE = 12
+	RPC_MSGTYPE            = 13 // message type used by the net/rpc codecs
+	JSONRPC_MSGTYPE        = 14 // message type used by the JSON-RPC 2.0 server and client
+	HTTP_MSGTYPE           = 15 // message type used by the http listener and round tripper
+	FILE_MSGTYPE           = 16 // message type used by SendFile and the FileReceiver
+	INSTANCE_MSGTYPE       = 17 // message type used by SingleInstance to forward the payload of a later launch
 	ENCRYPT_BY_DEFAULT     = true
 	DEFAULT_NETWORK_TYPE   = "tcp"
 	DEFAULT_NETWORK_HOST   = "127.0.0.1"